@@ -16,11 +16,21 @@ import (
 var (
 	rootCmd = &cobra.Command{
 		Use:   "gowmbus-analyze [hex]",
-		Short: "Decode Wireless M-Bus telegrams",
-		Long:  "gowmbus-analyze decodes Wireless M-Bus telegrams using the gowmbus library.",
+		Short: "Decode Wireless and wired M-Bus telegrams",
+		Long:  "gowmbus-analyze decodes Wireless M-Bus telegrams and wired M-Bus long frames using the gowmbus library.",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts := gowmbus.AnalyzeOptions{KeyHex: keyHex}
+			linkCRC, err := gowmbus.ParseLinkCRC(crcMode)
+			if err != nil {
+				return err
+			}
+			opts := gowmbus.AnalyzeOptions{
+				KeyHex:          keyHex,
+				LinkCRC:         linkCRC,
+				Driver:          driverName,
+				DriverFiles:     driverFiles,
+				CompatTimestamp: compatTimestamp,
+			}
 			ctx := cmd.Context()
 			if len(args) == 0 {
 				return runInteractive(ctx, opts)
@@ -29,11 +39,20 @@ var (
 		},
 	}
 
-	keyHex string
+	keyHex      string
+	crcMode     string
+	driverName  string
+	driverFiles []string
+
+	compatTimestamp bool
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&keyHex, "key", "", "hex-encoded 16-byte AES key (32 hex chars)")
+	rootCmd.PersistentFlags().StringVar(&crcMode, "crc", "none", "link-layer CRC handling: none, auto, a (format A) or b (format B)")
+	rootCmd.PersistentFlags().StringVar(&driverName, "driver", "", "force a driver by name instead of detecting it (e.g. hydrodigit, hydrocalm4, auto)")
+	rootCmd.PersistentFlags().BoolVar(&compatTimestamp, "compat-timestamp", false, "report the wmbusmeters placeholder timestamp instead of the receive time")
+	rootCmd.PersistentFlags().StringSliceVar(&driverFiles, "driver-file", nil, "load declarative driver definitions from JSON/YAML files or directories (repeatable)")
 }
 
 func main() {
@@ -3,6 +3,8 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
 	"errors"
 	"fmt"
 
@@ -12,22 +14,132 @@ import (
 var (
 	ErrKeyRequired = errors.New("encrypted telegram: AES key required (use --key)")
 	ErrInvalidKey  = errors.New("encrypted telegram: AES key rejected (bad plaintext)")
+	// ErrAuthentication reports an AFL MAC that does not match the telegram.
+	ErrAuthentication = errors.New("encrypted telegram: message authentication failed (MAC mismatch)")
+	// ErrUnsupportedMode reports a TPL security mode this package cannot
+	// decrypt.
+	ErrUnsupportedMode = errors.New("encrypted telegram: unsupported security mode")
 )
 
-const securityModeAesCbcIV = 5
+const (
+	ellEncryptionAesCtr    = 1
+	kdfSelectionOMS        = 1
+	kdfEncryptionFromMeter = 0x00
+	kdfMACFromMeter        = 0x01
+)
 
-// Decrypt mutates the payload when the content looks encrypted.
+// Decrypt mutates the payload when the content looks encrypted. Security
+// modes other than 0, 5 and 7 are rejected rather than read as plaintext.
 func Decrypt(t *frame.Telegram, key []byte) error {
+	if err := checkSecurityMode(t); err != nil {
+		return err
+	}
 	if !needsDecryption(t) {
 		return nil
 	}
 	if len(key) == 0 {
 		return ErrKeyRequired
 	}
-	return decryptCBC(t, key)
+	if t.TPL.Present && t.TPL.SecurityMode == frame.SecurityAesCbcNoIV {
+		return decryptMode7(t, key)
+	}
+	return decryptCBC(t, key, buildShortIV(t))
+}
+
+// DecryptELL decrypts an Extended Link Layer session (CI 0x8D) encrypted with
+// AES-128-CTR, verifies the payload CRC and decodes the chained transport
+// layer into the telegram.
+func DecryptELL(t *frame.Telegram, key []byte) error {
+	if !t.ELL.Encrypted() {
+		return nil
+	}
+	if len(key) == 0 {
+		return ErrKeyRequired
+	}
+	if mode := t.ELL.EncryptionMode(); mode != ellEncryptionAesCtr {
+		return fmt.Errorf("unsupported ELL encryption mode %d", mode)
+	}
+	if len(t.Payload) < 3 {
+		return fmt.Errorf("ELL payload too short: %d bytes", len(t.Payload))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("invalid AES key: %w", err)
+	}
+	plaintext := make([]byte, len(t.Payload))
+	cipher.NewCTR(block, buildELLIV(t)).XORKeyStream(plaintext, t.Payload)
+	crc := binary.LittleEndian.Uint16(plaintext[:2])
+	if frame.CRC16(plaintext[2:]) != crc {
+		return ErrInvalidKey
+	}
+	t.ELL.PayloadCRC = crc
+	return frame.ParseTransport(t, plaintext[2:])
+}
+
+// decryptMode7 verifies the AFL MAC and decrypts the payload with a session
+// key derived from the message counter; mode 7 uses a zero IV.
+func decryptMode7(t *frame.Telegram, key []byte) error {
+	if !t.AFL.Present || !t.AFL.HasMessageCounter() || len(t.AFL.MAC) == 0 {
+		return fmt.Errorf("security mode 7 requires an AFL with message counter and MAC")
+	}
+	if kdf := t.TPL.KDF(); kdf != kdfSelectionOMS {
+		return fmt.Errorf("unsupported key derivation function %d", kdf)
+	}
+	kmac, err := deriveKey(t, key, kdfMACFromMeter)
+	if err != nil {
+		return err
+	}
+	if err := verifyAFLMAC(t, kmac); err != nil {
+		return err
+	}
+	kenc, err := deriveKey(t, key, kdfEncryptionFromMeter)
+	if err != nil {
+		return err
+	}
+	return decryptCBC(t, kenc, make([]byte, aes.BlockSize))
+}
+
+// deriveKey implements the OMS key derivation: CMAC over the derivation
+// constant, message counter and meter ID padded with 0x07.
+func deriveKey(t *frame.Telegram, key []byte, constant byte) ([]byte, error) {
+	input := make([]byte, aes.BlockSize)
+	input[0] = constant
+	binary.LittleEndian.PutUint32(input[1:5], t.AFL.MessageCounter)
+	id := t.Identity()
+	copy(input[5:9], id.ID[:])
+	for i := 9; i < len(input); i++ {
+		input[i] = 0x07
+	}
+	return cmac(key, input)
+}
+
+// verifyAFLMAC recomputes the AFL MAC over the message control byte, the
+// fields it selects (key information, counter, message length) and the
+// transport layer as transmitted.
+func verifyAFLMAC(t *frame.Telegram, kmac []byte) error {
+	input := make([]byte, 0, 9+len(t.TPL.Raw))
+	input = append(input, t.AFL.MessageControl)
+	if t.AFL.KeyInfoInMAC() {
+		input = binary.LittleEndian.AppendUint16(input, t.AFL.KeyInfo)
+	}
+	if t.AFL.CounterInMAC() {
+		input = binary.LittleEndian.AppendUint32(input, t.AFL.MessageCounter)
+	}
+	if t.AFL.LengthInMAC() {
+		input = binary.LittleEndian.AppendUint16(input, t.AFL.MessageLength)
+	}
+	input = append(input, t.TPL.Raw...)
+	mac, err := cmac(kmac, input)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(mac[:len(t.AFL.MAC)], t.AFL.MAC) != 1 {
+		return ErrAuthentication
+	}
+	return nil
 }
 
-func decryptCBC(t *frame.Telegram, key []byte) error {
+func decryptCBC(t *frame.Telegram, key, iv []byte) error {
 	required := encryptedPrefixLen(t)
 	if required == 0 {
 		return ErrInvalidKey
@@ -41,7 +153,6 @@ func decryptCBC(t *frame.Telegram, key []byte) error {
 	}
 	ciphertext := make([]byte, required)
 	copy(ciphertext, t.Payload[:required])
-	iv := buildShortIV(t)
 	cipher.NewCBCDecrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
 	if !looksLikePlaintext(ciphertext) {
 		return ErrInvalidKey
@@ -54,16 +165,34 @@ func decryptCBC(t *frame.Telegram, key []byte) error {
 	return nil
 }
 
+// buildShortIV derives the mode 5 IV from the meter identity, which is the
+// long transport header address when present.
 func buildShortIV(t *frame.Telegram) []byte {
+	id := t.Identity()
+	iv := make([]byte, 16)
+	iv[0] = byte(id.Manufacturer)
+	iv[1] = byte(id.Manufacturer >> 8)
+	copy(iv[2:6], id.ID[:])
+	iv[6] = id.Version
+	iv[7] = id.DeviceType
+	for i := 8; i < 16; i++ {
+		iv[i] = t.AccessNumber
+	}
+	return iv
+}
+
+// buildELLIV assembles the AES-CTR counter block from the link-layer address,
+// the communication control field and the session number. Frame number and
+// block counter start at zero.
+func buildELLIV(t *frame.Telegram) []byte {
 	iv := make([]byte, 16)
 	iv[0] = byte(t.Manufacturer)
 	iv[1] = byte(t.Manufacturer >> 8)
 	copy(iv[2:6], t.MeterID[:])
 	iv[6] = t.Version
 	iv[7] = t.DeviceType
-	for i := 8; i < 16; i++ {
-		iv[i] = t.AccessNumber
-	}
+	iv[8] = t.ELL.Communication
+	binary.LittleEndian.PutUint32(iv[9:13], t.ELL.SessionNumber)
 	return iv
 }
 
@@ -79,6 +208,21 @@ func looksLikePlaintext(b []byte) bool {
 	return low <= 0x0D
 }
 
+func checkSecurityMode(t *frame.Telegram) error {
+	if !t.TPL.Present || len(t.Payload) == 0 {
+		return nil
+	}
+	switch mode := t.TPL.SecurityMode; mode {
+	case frame.SecurityNone, frame.SecurityAesCbcIV, frame.SecurityAesCbcNoIV:
+		return nil
+	default:
+		if t.TPL.SecurityModeSupported() {
+			return fmt.Errorf("%w %d", ErrUnsupportedMode, mode)
+		}
+		return fmt.Errorf("%w %d (reserved or manufacturer specific)", ErrUnsupportedMode, mode)
+	}
+}
+
 func needsDecryption(t *frame.Telegram) bool {
 	if len(t.Payload) == 0 {
 		return false
@@ -87,7 +231,7 @@ func needsDecryption(t *frame.Telegram) bool {
 		return false
 	}
 	if t.TPL.Present {
-		return t.TPL.SecurityMode == securityModeAesCbcIV
+		return t.TPL.SecurityMode == frame.SecurityAesCbcIV || t.TPL.SecurityMode == frame.SecurityAesCbcNoIV
 	}
 	return !looksLikePlaintext(t.Payload)
 }
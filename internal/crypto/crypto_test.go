@@ -0,0 +1,229 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/d21d3q/gowmbus/internal/frame"
+)
+
+var testKey = []byte{
+	0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+	0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F,
+}
+
+func TestDecryptELL(t *testing.T) {
+	raw := buildELLTelegram(t, testKey, "7A76000000046D25AA153A0C0300000000")
+	tg, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("frame.Parse: %v", err)
+	}
+	if !tg.ELL.Encrypted() {
+		t.Fatalf("expected encrypted ELL")
+	}
+	if err := DecryptELL(&tg, testKey); err != nil {
+		t.Fatalf("DecryptELL: %v", err)
+	}
+	if tg.AppCI != 0x7A || tg.AccessNumber != 0x76 {
+		t.Fatalf("transport not decoded: CI 0x%02X access 0x%02X", tg.AppCI, tg.AccessNumber)
+	}
+	if got := hex.EncodeToString(tg.Payload); got != "046d25aa153a0c0300000000" {
+		t.Fatalf("unexpected payload %s", got)
+	}
+}
+
+func TestDecryptELLWrongKey(t *testing.T) {
+	raw := buildELLTelegram(t, testKey, "7A76000000046D25AA153A0C0300000000")
+	tg, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("frame.Parse: %v", err)
+	}
+	wrong := make([]byte, 16)
+	if err := DecryptELL(&tg, wrong); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+	if err := DecryptELL(&tg, nil); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("expected ErrKeyRequired, got %v", err)
+	}
+}
+
+// buildELLTelegram wraps the transport layer in a CI 0x8D ELL encrypted with
+// AES-CTR for meter 05171338.
+func buildELLTelegram(t *testing.T, key []byte, transportHex string) []byte {
+	t.Helper()
+	transport, err := hex.DecodeString(transportHex)
+	if err != nil {
+		t.Fatalf("hex decode: %v", err)
+	}
+	header := []byte{0x00, 0x44, 0xB4, 0x09, 0x38, 0x13, 0x17, 0x05, 0x1A, 0x0D, 0x8D, 0x20, 0x49}
+	sn := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sn, 1<<29|0x1234)
+	plain := make([]byte, 2, 2+len(transport))
+	binary.LittleEndian.PutUint16(plain, frame.CRC16(transport))
+	plain = append(plain, transport...)
+
+	iv := make([]byte, 16)
+	copy(iv[0:8], header[2:10])
+	iv[8] = header[11]
+	copy(iv[9:13], sn)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes: %v", err)
+	}
+	cipher.NewCTR(block, iv).XORKeyStream(plain, plain)
+
+	raw := append(header, sn...)
+	raw = append(raw, plain...)
+	raw[0] = byte(len(raw) - 1)
+	return raw
+}
+
+func TestCMAC(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	cases := []struct {
+		msg  string
+		want string
+	}{
+		{"", "bb1d6929e95937287fa37d129b756746"},
+		{"6bc1bee22e409f96e93d7e117393172a", "070a16b46b4d4144f79bdd9dd04a287c"},
+		{"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411", "dfa66747de9ae63030ca32611497c827"},
+		{"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710", "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+	for _, tc := range cases {
+		msg, _ := hex.DecodeString(tc.msg)
+		mac, err := cmac(key, msg)
+		if err != nil {
+			t.Fatalf("cmac: %v", err)
+		}
+		if got := hex.EncodeToString(mac); got != tc.want {
+			t.Fatalf("cmac(%s) = %s, want %s", tc.msg, got, tc.want)
+		}
+	}
+}
+
+func TestDecryptMode7(t *testing.T) {
+	raw := buildMode7Telegram(t, testKey, "2F2F0C1366380000046D27287E2A2F2F")
+	tg, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("frame.Parse: %v", err)
+	}
+	if tg.TPL.SecurityMode != 7 || !tg.AFL.Present {
+		t.Fatalf("unexpected headers TPL %+v AFL %+v", tg.TPL, tg.AFL)
+	}
+	if err := Decrypt(&tg, testKey); err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if got := hex.EncodeToString(tg.Payload); got != "0c1366380000046d27287e2a2f2f" {
+		t.Fatalf("unexpected payload %s", got)
+	}
+}
+
+// TestDecryptMode7KnownAnswer checks a fixed mode 7 telegram for meter
+// 86868686, message counter 0x00000102 and key 000102...0F. Kenc, Kmac and
+// the MAC were computed with a separate AES-128/CMAC implementation that
+// reproduces the FIPS-197 and RFC 4493 vectors, so they do not depend on the
+// code under test.
+func TestDecryptMode7KnownAnswer(t *testing.T) {
+	raw, _ := hex.DecodeString("3044B409868686861307900F002C25020100005C4A95E76C35687F7A2A00100710C46BFE25E0729CD37F64B0A3B2BC115F")
+	tg, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("frame.Parse: %v", err)
+	}
+	kenc, err := deriveKey(&tg, testKey, kdfEncryptionFromMeter)
+	if err != nil || hex.EncodeToString(kenc) != "d2b69802c877ad4b46e8c33ba48b89ae" {
+		t.Fatalf("Kenc = %x (%v)", kenc, err)
+	}
+	kmac, err := deriveKey(&tg, testKey, kdfMACFromMeter)
+	if err != nil || hex.EncodeToString(kmac) != "552a9872caf7c547079e604fe8eef47d" {
+		t.Fatalf("Kmac = %x (%v)", kmac, err)
+	}
+	if got := hex.EncodeToString(tg.AFL.MAC); got != "5c4a95e76c35687f" {
+		t.Fatalf("AFL MAC = %s", got)
+	}
+	if err := Decrypt(&tg, testKey); err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if got := hex.EncodeToString(tg.Payload); got != "0c1366380000046d27287e2a2f2f" {
+		t.Fatalf("unexpected payload %s", got)
+	}
+}
+
+func TestDecryptMode7BadMAC(t *testing.T) {
+	raw := buildMode7Telegram(t, testKey, "2F2F0C1366380000046D27287E2A2F2F")
+	tg, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("frame.Parse: %v", err)
+	}
+	wrong := make([]byte, 16)
+	if err := Decrypt(&tg, wrong); !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("expected ErrAuthentication, got %v", err)
+	}
+}
+
+// buildMode7Telegram encrypts the plaintext blocks with security mode 7 and
+// prefixes them with an AFL carrying the message counter and an 8-byte MAC.
+func buildMode7Telegram(t *testing.T, key []byte, plainHex string) []byte {
+	t.Helper()
+	plain, err := hex.DecodeString(plainHex)
+	if err != nil {
+		t.Fatalf("hex decode: %v", err)
+	}
+	const counter = 0x00000102
+	id := []byte{0x86, 0x86, 0x86, 0x86}
+	derive := func(constant byte) []byte {
+		input := []byte{constant, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(input[1:], counter)
+		input = append(input, id...)
+		for len(input) < 16 {
+			input = append(input, 0x07)
+		}
+		k, err := cmac(key, input)
+		if err != nil {
+			t.Fatalf("cmac: %v", err)
+		}
+		return k
+	}
+	block, err := aes.NewCipher(derive(0x00))
+	if err != nil {
+		t.Fatalf("aes: %v", err)
+	}
+	cipher.NewCBCEncrypter(block, make([]byte, 16)).CryptBlocks(plain, plain)
+
+	blocks := byte(len(plain) / 16)
+	tpl := []byte{0x7A, 0x2A, 0x00, blocks << 4, 0x07, 0x10}
+	tpl = append(tpl, plain...)
+
+	macInput := []byte{0x25}
+	macInput = binary.LittleEndian.AppendUint32(macInput, counter)
+	macInput = append(macInput, tpl...)
+	mac, err := cmac(derive(0x01), macInput)
+	if err != nil {
+		t.Fatalf("cmac: %v", err)
+	}
+	afl := []byte{0x90, 0x0F, 0x00, 0x2C, 0x25}
+	afl = binary.LittleEndian.AppendUint32(afl, counter)
+	afl = append(afl, mac[:8]...)
+
+	raw := []byte{0x00, 0x44, 0xB4, 0x09}
+	raw = append(raw, id...)
+	raw = append(raw, 0x13, 0x07)
+	raw = append(raw, afl...)
+	raw = append(raw, tpl...)
+	raw[0] = byte(len(raw) - 1)
+	return raw
+}
+
+func TestDecryptUnsupportedMode(t *testing.T) {
+	raw, _ := hex.DecodeString("1044B4098686868613077A2A0000080102")
+	tg, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := Decrypt(&tg, testKey); !errors.Is(err, ErrUnsupportedMode) {
+		t.Fatalf("expected unsupported mode error, got %v", err)
+	}
+}
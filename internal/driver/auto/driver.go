@@ -0,0 +1,175 @@
+// Package auto provides the fallback driver that decodes any telegram whose
+// application layer follows the EN 13757-3 data record format.
+package auto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/d21d3q/gowmbus/internal/driver"
+	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
+	"github.com/d21d3q/gowmbus/internal/frame"
+	"github.com/d21d3q/gowmbus/internal/options"
+	"github.com/d21d3q/gowmbus/internal/records"
+)
+
+func init() {
+	driver.RegisterFallback(Driver{})
+}
+
+// Driver emits every current data record under a name derived from its VIF,
+// tariff and subunit. Historic storage slots are reported by History.
+type Driver struct{}
+
+var _ driver.PartialReporter = Driver{}
+
+// Name returns the canonical driver name.
+func (Driver) Name() string { return "auto" }
+
+// PartialFields exposes basic metadata when parsing fails.
+func (Driver) PartialFields(t *frame.Telegram) map[string]any {
+	return map[string]any{
+		"_":      "telegram",
+		"id":     t.MeterIDString(),
+		"meter":  "auto",
+		"media":  t.Media(),
+		"status": t.Status.Text(nil),
+	}
+}
+
+// Process decodes the current values (storage number 0) record by record.
+func (d Driver) Process(ctx context.Context, t *frame.Telegram) (map[string]any, error) {
+	report, err := d.Report(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	return report.Fields, nil
+}
+
+var _ driver.Reporter = Driver{}
+
+// Report decodes the current values and each historic storage slot, whose
+// records are named as the current ones without the storage suffix. Every
+// numeric record is also reported as a measurement with the quantity and
+// unit of the VIF catalogue.
+func (d Driver) Report(ctx context.Context, t *frame.Telegram) (driver.Report, error) {
+	recs, err := records.Decode(t.Payload)
+	if err != nil {
+		return driver.Report{}, err
+	}
+	current, slots := wmbus.SplitStorage(recs)
+	fields := d.PartialFields(t)
+	fields["timestamp"] = options.Timestamp(ctx)
+	for _, rec := range current {
+		if rec.IsManufacturerData() && len(rec.Data) > 0 {
+			fields["manufacturer_data"] = fmt.Sprintf("%X", rec.Data)
+		}
+	}
+	measurements, err := decodeFields(fields, current)
+	if err != nil {
+		return driver.Report{}, err
+	}
+
+	report := driver.Report{
+		Fields:       fields,
+		History:      make([]driver.History, 0, len(slots)),
+		Measurements: measurements,
+	}
+	var errs []error
+	for _, slot := range slots {
+		entry := driver.History{Storage: slot.Storage, Fields: map[string]any{}}
+		if slot.HasDate && !slot.Date.Invalid {
+			entry.Date = slot.Date.Time
+		}
+		historic, err := decodeFields(entry.Fields, slot.Records)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("storage %d: %w", slot.Storage, err))
+			continue
+		}
+		report.History = append(report.History, entry)
+		report.Measurements = append(report.Measurements, historic...)
+	}
+	report.HistoryErr = errors.Join(errs...)
+	return report, nil
+}
+
+// decodeFields adds the data records to fields, named without the storage
+// suffix, and returns a measurement for each numeric value.
+func decodeFields(fields map[string]any, recs []records.Record) ([]driver.Measurement, error) {
+	var measurements []driver.Measurement
+	for _, rec := range recs {
+		if rec.IsManufacturerData() {
+			continue
+		}
+		value, ok, err := recordField(rec)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		named := rec
+		named.Storage = 0
+		name := uniqueKey(fields, named.FieldName())
+		fields[name] = value
+		if f, numeric := value.(float64); numeric {
+			info, _ := records.DescribeVIF(rec.VIF)
+			measurements = append(measurements, driver.NewMeasurement(name, info.Quantity, f, info.Unit, rec))
+		}
+	}
+	return measurements, nil
+}
+
+// recordField converts a record to its reported value. The boolean is false
+// for records without data and for values the meter marked as invalid.
+func recordField(rec records.Record) (any, bool, error) {
+	if dt, ok, err := wmbus.RecordDateTime(rec); ok {
+		if err != nil {
+			return nil, false, fmt.Errorf("decode VIF 0x%02X: %w", rec.VIF, err)
+		}
+		if dt.Invalid {
+			return nil, false, nil
+		}
+		return dt.Time.Format(wmbus.RecordLayout(rec)), true, nil
+	}
+	if tod, ok, err := wmbus.RecordTimeOfDay(rec); ok {
+		if err != nil {
+			return nil, false, fmt.Errorf("decode VIF 0x%02X: %w", rec.VIF, err)
+		}
+		return time.Time{}.Add(tod).Format(wmbus.TimeLayout), true, nil
+	}
+	value, err := wmbus.RecordValue(rec)
+	if err != nil {
+		return nil, false, fmt.Errorf("decode VIF 0x%02X: %w", rec.VIF, err)
+	}
+	if !value.Valid() {
+		return nil, false, nil
+	}
+	switch value.Kind {
+	case wmbus.ValueString:
+		return value.Text, true, nil
+	case wmbus.ValueBinary:
+		return fmt.Sprintf("%X", value.Bytes), true, nil
+	}
+	scale := 1.0
+	if info, known := records.DescribeVIF(rec.VIF); known {
+		scale = info.Scale
+	}
+	return value.Float() * scale, true, nil
+}
+
+// uniqueKey appends a counter when a telegram carries several records that
+// map to the same name.
+func uniqueKey(fields map[string]any, key string) string {
+	if _, taken := fields[key]; !taken {
+		return key
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", key, n)
+		if _, taken := fields[candidate]; !taken {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package declarative builds drivers from JSON or YAML definitions that map
+// data records to field names, so simple meters need no Go code.
+package declarative
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/d21d3q/gowmbus/internal/driver"
+	"github.com/d21d3q/gowmbus/internal/frame"
+)
+
+// Definition is the file format of a declarative driver.
+//
+//	name: mymeter
+//	media: water
+//	status_bits: {0x20: leak}
+//	detection:
+//	  - manufacturer: KAM
+//	    ci: 0x7A
+//	    device_types: [0x07]
+//	    versions: [{min: 0x10, max: 0x1F}]
+//	fields:
+//	  - name: total_m3
+//	    quantity: volume
+//	    unit: m3
+type Definition struct {
+	Name string `yaml:"name"`
+	// Media overrides the medium derived from the device type.
+	Media    string `yaml:"media"`
+	Priority int    `yaml:"priority"`
+	// StatusBits names the manufacturer-specific status bits, keyed by mask
+	// (0x20, 0x40 or 0x80).
+	StatusBits map[Number]string `yaml:"status_bits"`
+	Detection  []DetectionRule   `yaml:"detection"`
+	Fields     []FieldRule       `yaml:"fields"`
+}
+
+// DetectionRule mirrors driver.Detection.
+type DetectionRule struct {
+	Manufacturer Manufacturer  `yaml:"manufacturer"`
+	CI           Number        `yaml:"ci"`
+	DeviceTypes  []Number      `yaml:"device_types"`
+	Versions     []VersionRule `yaml:"versions"`
+}
+
+// VersionRule is an inclusive range of meter versions.
+type VersionRule struct {
+	Min Number `yaml:"min"`
+	Max Number `yaml:"max"`
+}
+
+// FieldRule maps the records it matches to a named field. A record matches
+// when every given selector does: the DIF, the combined VIF code or the VIF
+// quantity name (see records.DescribeVIF), the exact VIFE chain, and the
+// storage, tariff, subunit and function numbers. Omitted numbers match 0,
+// "any" matches every value.
+type FieldRule struct {
+	Name     string   `yaml:"name"`
+	DIF      *Number  `yaml:"dif"`
+	VIF      *Number  `yaml:"vif"`
+	Quantity string   `yaml:"quantity"`
+	VIFE     []Number `yaml:"vife"`
+	Storage  Selector `yaml:"storage"`
+	Tariff   Selector `yaml:"tariff"`
+	Subunit  Selector `yaml:"subunit"`
+	Function Selector `yaml:"function"`
+	// Unit converts the value from the unit implied by the VIF. Empty keeps
+	// the VIF unit.
+	Unit string `yaml:"unit"`
+}
+
+// Number accepts an integer in decimal or with a 0x/0o/0b prefix, either as
+// a number or as a string, so JSON files can use hex codes too.
+type Number uint32
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (n *Number) UnmarshalYAML(node *yaml.Node) error {
+	v, err := strconv.ParseUint(strings.TrimSpace(node.Value), 0, 32)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid number %q", node.Line, node.Value)
+	}
+	*n = Number(v)
+	return nil
+}
+
+// Manufacturer accepts a three-letter FLAG code such as "BMT" or the
+// numeric manufacturer field.
+type Manufacturer uint16
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *Manufacturer) UnmarshalYAML(node *yaml.Node) error {
+	value := strings.TrimSpace(node.Value)
+	if id, err := frame.ManufacturerID(value); err == nil {
+		*m = Manufacturer(id)
+		return nil
+	}
+	v, err := strconv.ParseUint(value, 0, 16)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid manufacturer %q (want a FLAG code or number)", node.Line, node.Value)
+	}
+	*m = Manufacturer(v)
+	return nil
+}
+
+// Selector matches a record number exactly or, when Any is set, always.
+type Selector struct {
+	Any   bool
+	Value int
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Selector) UnmarshalYAML(node *yaml.Node) error {
+	if strings.EqualFold(strings.TrimSpace(node.Value), "any") {
+		*s = Selector{Any: true}
+		return nil
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(node.Value))
+	if err != nil {
+		return fmt.Errorf("line %d: invalid selector %q (want a number or \"any\")", node.Line, node.Value)
+	}
+	*s = Selector{Value: v}
+	return nil
+}
+
+func (s Selector) matches(v int) bool {
+	return s.Any || s.Value == v
+}
+
+// Parse decodes a definition. JSON is accepted as it is a subset of YAML.
+func Parse(data []byte) (Definition, error) {
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return Definition{}, err
+	}
+	if err := def.validate(); err != nil {
+		return Definition{}, err
+	}
+	return def, nil
+}
+
+func (def Definition) validate() error {
+	if def.Name == "" {
+		return fmt.Errorf("driver definition without name")
+	}
+	if len(def.Detection) == 0 {
+		return fmt.Errorf("driver %s: no detection rules", def.Name)
+	}
+	for i, det := range def.Detection {
+		if det.CI > 0xFF {
+			return fmt.Errorf("driver %s: detection %d out of range", def.Name, i)
+		}
+		for _, dt := range det.DeviceTypes {
+			if dt > 0xFF {
+				return fmt.Errorf("driver %s: device type 0x%X out of range", def.Name, uint32(dt))
+			}
+		}
+		for _, vr := range det.Versions {
+			if vr.Min > 0xFF || vr.Max > 0xFF || vr.Min > vr.Max {
+				return fmt.Errorf("driver %s: invalid version range %d-%d", def.Name, vr.Min, vr.Max)
+			}
+		}
+	}
+	for mask := range def.StatusBits {
+		if mask != 0x20 && mask != 0x40 && mask != 0x80 {
+			return fmt.Errorf("driver %s: status bit mask 0x%X is not manufacturer specific", def.Name, uint32(mask))
+		}
+	}
+	for i, f := range def.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("driver %s: field %d without name", def.Name, i)
+		}
+		if f.VIF == nil && f.Quantity == "" {
+			return fmt.Errorf("driver %s: field %s needs a vif or quantity", def.Name, f.Name)
+		}
+	}
+	return nil
+}
+
+// detections converts the rules into registry detections.
+func (def Definition) detections() []driver.Detection {
+	out := make([]driver.Detection, 0, len(def.Detection))
+	for _, rule := range def.Detection {
+		det := driver.Detection{
+			Manufacturer: uint16(rule.Manufacturer),
+			CI:           byte(rule.CI),
+		}
+		for _, dt := range rule.DeviceTypes {
+			det.DeviceTypes = append(det.DeviceTypes, byte(dt))
+		}
+		for _, vr := range rule.Versions {
+			det.Versions = append(det.Versions, driver.VersionRange{Min: byte(vr.Min), Max: byte(vr.Max)})
+		}
+		out = append(out, det)
+	}
+	return out
+}
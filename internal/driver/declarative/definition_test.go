@@ -0,0 +1,148 @@
+package declarative
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d21d3q/gowmbus/internal/frame"
+)
+
+const combinedHeatCool = "3A44B409381317051A0D8C00497A7A000000046D29AA153A0C03000000000C13000000008C1003050000008C1013040000000F6401000000000000"
+
+func TestParseJSON(t *testing.T) {
+	def, err := Parse([]byte(`{
+		"name": "json-meter",
+		"detection": [{"manufacturer": "0x09B4", "ci": 140, "versions": [{"min": 0, "max": "0x1F"}]}],
+		"fields": [{"name": "cooling_kwh", "vif": "0x03", "tariff": 1}]
+	}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	dets := def.detections()
+	if len(dets) != 1 || dets[0].Manufacturer != 0x09B4 || dets[0].CI != 0x8C || dets[0].Versions[0].Max != 0x1F {
+		t.Fatalf("unexpected detection %+v", dets)
+	}
+	if def.Fields[0].Tariff.Value != 1 || *def.Fields[0].VIF != 0x03 {
+		t.Fatalf("unexpected field rule %+v", def.Fields[0])
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := map[string]string{
+		"no name":      "detection: [{manufacturer: 1, ci: 0x7A}]",
+		"no detection": "name: x",
+		"no selector":  "name: x\ndetection: [{manufacturer: 1, ci: 0x7A}]\nfields: [{name: a}]",
+		"bad number":   "name: x\ndetection: [{manufacturer: zz, ci: 0x7A}]",
+	}
+	for name, src := range cases {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	drv, err := LoadFile("../../../testdata/drivers/hydrocalm4.yaml")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	raw, err := hex.DecodeString(combinedHeatCool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	telegram, err := frame.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse frame: %v", err)
+	}
+	fields, err := drv.Process(context.Background(), &telegram)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	expected := map[string]any{
+		"device_datetime":   "2024-10-21 10:41",
+		"total_heating_kwh": 0.0,
+		"total_cooling_kwh": 0.005,
+		"total_heating_m3":  0.0,
+		"total_cooling_m3":  0.004,
+		"media":             "heat/cooling load",
+	}
+	for key, want := range expected {
+		got := fields[key]
+		if w, ok := want.(float64); ok {
+			g, isFloat := got.(float64)
+			if !isFloat || g-w > 1e-9 || w-g > 1e-9 {
+				t.Fatalf("%s: expected %v, got %v", key, want, got)
+			}
+			continue
+		}
+		if got != want {
+			t.Fatalf("%s: expected %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestStatusBits(t *testing.T) {
+	drv, err := New(mustParse(t, "name: x\nstatus_bits: {0x20: leak, 0x80: burst}\ndetection: [{manufacturer: KAM, ci: 0x7A}]"))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	bits := drv.StatusBits()
+	if bits[0x20] != "leak" || bits[0x80] != "burst" {
+		t.Fatalf("unexpected status bits %v", bits)
+	}
+	if _, err := Parse([]byte("name: x\nstatus_bits: {0x04: low}\ndetection: [{manufacturer: KAM, ci: 0x7A}]")); err == nil {
+		t.Fatalf("expected error for standard status bit")
+	}
+}
+
+func mustParse(t *testing.T, src string) Definition {
+	t.Helper()
+	def, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return def
+}
+
+func TestLoadFilesReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "meter.yaml")
+	write := func(name string, mtime time.Time) {
+		t.Helper()
+		src := "name: " + name + "\ndetection: [{manufacturer: 1, ci: 0x7A}]\nfields: [{name: a, vif: 0x13}]\n"
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	write("first", start)
+
+	t.Chdir(dir)
+	abs, err := LoadFiles(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	rel, err := LoadFiles("meter.yaml")
+	if err != nil {
+		t.Fatalf("load relative: %v", err)
+	}
+	if rel[0] != abs[0] {
+		t.Fatalf("relative and absolute path parsed twice")
+	}
+
+	write("second", start.Add(time.Minute))
+	reloaded, err := LoadFiles(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if reloaded[0].Name() != "second" {
+		t.Fatalf("edited file not reloaded, got %q", reloaded[0].Name())
+	}
+}
@@ -0,0 +1,341 @@
+package declarative
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d21d3q/gowmbus/internal/driver"
+	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
+	"github.com/d21d3q/gowmbus/internal/frame"
+	"github.com/d21d3q/gowmbus/internal/options"
+	"github.com/d21d3q/gowmbus/internal/records"
+	"github.com/d21d3q/gowmbus/pkg/units"
+)
+
+// Driver decodes telegrams according to a Definition.
+type Driver struct {
+	def Definition
+}
+
+var (
+	_ driver.PartialReporter = (*Driver)(nil)
+	_ driver.StatusNamer     = (*Driver)(nil)
+)
+
+// New validates the definition and returns its driver.
+func New(def Definition) (*Driver, error) {
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	return &Driver{def: def}, nil
+}
+
+// Name returns the name declared in the definition.
+func (d *Driver) Name() string { return d.def.Name }
+
+// StatusBits implements driver.StatusNamer.
+func (d *Driver) StatusBits() frame.StatusBits {
+	bits := make(frame.StatusBits, len(d.def.StatusBits))
+	for mask, name := range d.def.StatusBits {
+		bits[byte(mask)] = name
+	}
+	return bits
+}
+
+// Priority returns the lookup priority declared in the definition.
+func (d *Driver) Priority() int { return d.def.Priority }
+
+// Detections returns the registry detections declared in the definition.
+func (d *Driver) Detections() []driver.Detection { return d.def.detections() }
+
+// PartialFields exposes basic metadata when parsing fails.
+func (d *Driver) PartialFields(t *frame.Telegram) map[string]any {
+	fields := map[string]any{
+		"_":      "telegram",
+		"id":     t.MeterIDString(),
+		"meter":  d.def.Name,
+		"media":  t.Media(),
+		"status": t.Status.Text(d.StatusBits()),
+	}
+	if d.def.Media != "" {
+		fields["media"] = d.def.Media
+	}
+	return fields
+}
+
+// Process applies the field rules to every data record of the payload.
+func (d *Driver) Process(ctx context.Context, t *frame.Telegram) (map[string]any, error) {
+	report, err := d.Report(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	return report.Fields, nil
+}
+
+var _ driver.Reporter = (*Driver)(nil)
+
+// Report applies the field rules like Process and reports each numeric field
+// as a measurement in the unit the rule asked for, or the unit of the VIF
+// when it names none. When several records feed one field, the last one
+// wins for both.
+func (d *Driver) Report(ctx context.Context, t *frame.Telegram) (driver.Report, error) {
+	recs, err := wmbus.ParseRecords(t.Payload)
+	if err != nil {
+		return driver.Report{}, err
+	}
+	fields := d.PartialFields(t)
+	fields["timestamp"] = options.Timestamp(ctx)
+	// measured holds the measurement of each field; a zero value marks a
+	// field whose last value is not numeric.
+	measured := map[string]driver.Measurement{}
+	var order []string
+	for _, rec := range recs {
+		for _, rule := range d.def.Fields {
+			if !rule.matches(rec) {
+				continue
+			}
+			value, ok, err := rule.value(rec)
+			if err != nil {
+				return driver.Report{}, fmt.Errorf("%s: field %s: %w", d.def.Name, rule.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			fields[rule.Name] = value
+			if _, listed := measured[rule.Name]; !listed {
+				order = append(order, rule.Name)
+			}
+			number, numeric := value.(float64)
+			if !numeric {
+				measured[rule.Name] = driver.Measurement{}
+				continue
+			}
+			info, _ := records.DescribeVIF(rec.VIF)
+			unit := rule.Unit
+			if unit == "" {
+				unit = info.Unit
+			}
+			measured[rule.Name] = driver.NewMeasurement(rule.Name, info.Quantity, number, unit, rec)
+		}
+	}
+	var measurements []driver.Measurement
+	for _, name := range order {
+		if m := measured[name]; m.Name != "" {
+			measurements = append(measurements, m)
+		}
+	}
+	return driver.Report{Fields: fields, Measurements: measurements}, nil
+}
+
+func (f FieldRule) matches(rec records.Record) bool {
+	if f.DIF != nil && byte(*f.DIF) != rec.DIF {
+		return false
+	}
+	if f.VIF != nil && int(*f.VIF) != rec.VIF {
+		return false
+	}
+	if f.Quantity != "" {
+		info, ok := records.DescribeVIF(rec.VIF)
+		if !ok || info.Quantity != f.Quantity {
+			return false
+		}
+	}
+	if len(f.VIFE) != len(rec.VIFE) {
+		return false
+	}
+	for i, vife := range f.VIFE {
+		if byte(vife) != rec.VIFE[i] {
+			return false
+		}
+	}
+	return f.Storage.matches(rec.Storage) &&
+		f.Tariff.matches(rec.Tariff) &&
+		f.Subunit.matches(rec.Subunit) &&
+		f.Function.matches(rec.Function)
+}
+
+// value decodes the record and converts it to the requested unit. The
+// boolean is false for values the meter marked as invalid.
+func (f FieldRule) value(rec records.Record) (any, bool, error) {
+	if dt, ok, err := wmbus.RecordDateTime(rec); ok {
+		if err != nil {
+			return nil, false, err
+		}
+		if dt.Invalid {
+			return nil, false, nil
+		}
+		return dt.Time.Format(wmbus.RecordLayout(rec)), true, nil
+	}
+	info, known := records.DescribeVIF(rec.VIF)
+	raw, err := wmbus.RecordValue(rec)
+	if err != nil {
+		return nil, false, err
+	}
+	if !raw.Valid() {
+		return nil, false, nil
+	}
+	if raw.Kind == wmbus.ValueString {
+		return raw.Text, true, nil
+	}
+	if !raw.Numeric() {
+		return fmt.Sprintf("%X", raw.Bytes), true, nil
+	}
+	value := raw.Float()
+	if !known {
+		return value, true, nil
+	}
+	value *= info.Scale
+	if f.Unit == "" || f.Unit == info.Unit {
+		return value, true, nil
+	}
+	converted, err := units.ConvertQuantity(value, info.Quantity, info.Unit, f.Unit)
+	if err != nil {
+		return nil, false, err
+	}
+	return converted, true, nil
+}
+
+// LoadFile reads a definition from a .json, .yaml or .yml file.
+func LoadFile(path string) (*Driver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return New(def)
+}
+
+// cachedDriver is a parsed definition file and the state of the file when it
+// was parsed.
+type cachedDriver struct {
+	driver  *Driver
+	modTime time.Time
+	size    int64
+}
+
+var (
+	loadedMu sync.Mutex
+	// cache and registered are keyed by absolute path.
+	cache      = map[string]cachedDriver{}
+	registered = map[string]*Driver{}
+)
+
+// LoadFiles parses the definitions found at the given paths without
+// registering them. Directories are scanned for .json, .yaml and .yml files.
+// A parsed file is reused until its modification time or size changes.
+func LoadFiles(paths ...string) ([]*Driver, error) {
+	files, err := expand(paths)
+	if err != nil {
+		return nil, err
+	}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	return loadFiles(files)
+}
+
+func loadFiles(files []string) ([]*Driver, error) {
+	drivers := make([]*Driver, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		cached, ok := cache[file]
+		if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+			drv, err := LoadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			cached = cachedDriver{driver: drv, modTime: info.ModTime(), size: info.Size()}
+			cache[file] = cached
+		}
+		drivers = append(drivers, cached.driver)
+	}
+	return drivers, nil
+}
+
+// RegisterFiles loads the definitions found at the given paths and registers
+// them alongside the compiled drivers for the rest of the process. Each file
+// is registered only once, so the call may be repeated; a file that changed
+// since it was registered replaces its earlier driver.
+func RegisterFiles(paths ...string) error {
+	files, err := expand(paths)
+	if err != nil {
+		return err
+	}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	drivers, err := loadFiles(files)
+	if err != nil {
+		return err
+	}
+	for i, drv := range drivers {
+		prev, ok := registered[files[i]]
+		if ok && prev == drv {
+			continue
+		}
+		if ok {
+			driver.Unregister(prev)
+		}
+		for _, det := range drv.Detections() {
+			driver.RegisterPriority(det, drv, drv.Priority())
+		}
+		registered[files[i]] = drv
+	}
+	return nil
+}
+
+// Candidates returns the detections of drivers for use with
+// driver.LookupWith and driver.ByNameWith.
+func Candidates(drivers []*Driver) []driver.Candidate {
+	var out []driver.Candidate
+	for _, drv := range drivers {
+		for _, det := range drv.Detections() {
+			out = append(out, driver.Candidate{Detection: det, Driver: drv, Priority: drv.Priority()})
+		}
+	}
+	return out
+}
+
+func expand(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, abs)
+			continue
+		}
+		entries, err := os.ReadDir(abs)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".json", ".yaml", ".yml":
+				if !entry.IsDir() {
+					found = append(found, filepath.Join(abs, entry.Name()))
+				}
+			}
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
@@ -2,21 +2,24 @@ package hydrocalm4
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/d21d3q/gowmbus/internal/driver"
 	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
 	"github.com/d21d3q/gowmbus/internal/frame"
+	"github.com/d21d3q/gowmbus/internal/options"
+	"github.com/d21d3q/gowmbus/internal/records"
+	"github.com/d21d3q/gowmbus/pkg/units"
 )
 
 const (
-	manufacturerBMT  = 0x09B4
-	ciHydrocalm4     = 0x8C
-	deviceTypeHeat   = 0x0D
-	defaultTimestamp = "1111-11-11T11:11:11Z"
-	mediaHeat        = "heat/cooling load"
+	ciHydrocalm4   = 0x8C
+	deviceTypeHeat = 0x0D
 )
 
+var manufacturerBMT = frame.MustManufacturerID("BMT")
+
 func init() {
 	driver.Register(driver.Detection{
 		Manufacturer: manufacturerBMT,
@@ -39,72 +42,86 @@ func (Driver) PartialFields(t *frame.Telegram) map[string]any {
 		"_":      "telegram",
 		"id":     t.MeterIDString(),
 		"meter":  "hydrocalm4",
-		"media":  mediaHeat,
+		"media":  t.Media(),
 		"status": statusString(t),
 	}
 }
 
-// Process parses the telegram payload into structured fields.
-func (Driver) Process(_ context.Context, t *frame.Telegram) (map[string]any, error) {
-	payload := trimToApplication(t.Payload)
-	records, err := wmbus.ParseRecords(payload)
+// Process parses the current values (storage number 0) into structured
+// fields. Billing-date readings are reported by Report.
+func (d Driver) Process(ctx context.Context, t *frame.Telegram) (map[string]any, error) {
+	report, err := d.Report(ctx, t)
 	if err != nil {
 		return nil, err
 	}
-	values, err := aggregate(records)
+	return report.Fields, nil
+}
+
+var _ driver.Reporter = Driver{}
+
+// Report decodes the current values and each historic storage slot, the
+// latter under the date of its own date record, together with their
+// measurements. Energy is always kWh and power kW, also when the meter sent
+// MJ or MJ/h.
+func (Driver) Report(ctx context.Context, t *frame.Telegram) (driver.Report, error) {
+	records, err := wmbus.ParseRecords(t.Payload)
 	if err != nil {
-		return nil, err
+		return driver.Report{}, err
+	}
+	current, slots := wmbus.SplitStorage(records)
+	values, measurements, err := aggregate(current)
+	if err != nil {
+		return driver.Report{}, err
 	}
 	fields := map[string]any{
 		"_":         "telegram",
 		"id":        t.MeterIDString(),
 		"meter":     "hydrocalm4",
-		"media":     mediaHeat,
-		"timestamp": defaultTimestamp,
+		"media":     t.Media(),
+		"timestamp": options.Timestamp(ctx),
 		"status":    statusString(t),
 	}
 	if values.DeviceDateTime != "" {
 		fields["device_datetime"] = values.DeviceDateTime
 	}
-	if values.TotalHeatingKWh != nil {
-		fields["total_heating_kwh"] = *values.TotalHeatingKWh
-	}
-	if values.TotalCoolingKWh != nil {
-		fields["total_cooling_kwh"] = *values.TotalCoolingKWh
-	}
-	if values.TotalHeatingM3 != nil {
-		fields["total_heating_m3"] = *values.TotalHeatingM3
+	if values.DueDate != "" {
+		fields["due_date"] = values.DueDate
 	}
-	if values.TotalCoolingM3 != nil {
-		fields["total_cooling_m3"] = *values.TotalCoolingM3
-	}
-	if values.C1VolumeM3 != nil {
-		fields["c1_volume_m3"] = *values.C1VolumeM3
-	}
-	if values.C2VolumeM3 != nil {
-		fields["c2_volume_m3"] = *values.C2VolumeM3
-	}
-	if values.SupplyTempC != nil {
-		fields["supply_temperature_c"] = *values.SupplyTempC
-	}
-	if values.ReturnTempC != nil {
-		fields["return_temperature_c"] = *values.ReturnTempC
-	}
-	if values.VolumeFlowM3h != nil {
-		fields["volume_flow_m3h"] = *values.VolumeFlowM3h
+	values.addTo(fields)
+
+	report := driver.Report{
+		Fields:       fields,
+		History:      make([]driver.History, 0, len(slots)),
+		Measurements: measurements,
 	}
-	if values.PowerKW != nil {
-		fields["power_kw"] = *values.PowerKW
+	var errs []error
+	for _, slot := range slots {
+		values, historic, err := aggregate(slot.Records)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("storage %d: %w", slot.Storage, err))
+			continue
+		}
+		entry := driver.History{Storage: slot.Storage, Fields: map[string]any{}}
+		if slot.HasDate && !slot.Date.Invalid {
+			entry.Date = slot.Date.Time
+		}
+		values.addTo(entry.Fields)
+		report.History = append(report.History, entry)
+		report.Measurements = append(report.Measurements, historic...)
 	}
-	return fields, nil
+	report.HistoryErr = errors.Join(errs...)
+	return report, nil
 }
 
+// statusString reports the transport status byte; the manufacturer bits of
+// the Hydrocalm4 are not documented and show up as MANUFACTURER_0xNN.
 func statusString(t *frame.Telegram) string {
-	return "OK"
+	return t.Status.Text(nil)
 }
 
 type aggregateValues struct {
 	DeviceDateTime  string
+	DueDate         string
 	TotalHeatingKWh *float64
 	TotalCoolingKWh *float64
 	TotalHeatingM3  *float64
@@ -117,68 +134,133 @@ type aggregateValues struct {
 	PowerKW         *float64
 }
 
-func aggregate(recs []wmbus.Record) (aggregateValues, error) {
+// addTo copies the decoded readings into fields.
+func (v aggregateValues) addTo(fields map[string]any) {
+	if v.TotalHeatingKWh != nil {
+		fields["total_heating_kwh"] = *v.TotalHeatingKWh
+	}
+	if v.TotalCoolingKWh != nil {
+		fields["total_cooling_kwh"] = *v.TotalCoolingKWh
+	}
+	if v.TotalHeatingM3 != nil {
+		fields["total_heating_m3"] = *v.TotalHeatingM3
+	}
+	if v.TotalCoolingM3 != nil {
+		fields["total_cooling_m3"] = *v.TotalCoolingM3
+	}
+	if v.C1VolumeM3 != nil {
+		fields["c1_volume_m3"] = *v.C1VolumeM3
+	}
+	if v.C2VolumeM3 != nil {
+		fields["c2_volume_m3"] = *v.C2VolumeM3
+	}
+	if v.SupplyTempC != nil {
+		fields["supply_temperature_c"] = *v.SupplyTempC
+	}
+	if v.ReturnTempC != nil {
+		fields["return_temperature_c"] = *v.ReturnTempC
+	}
+	if v.VolumeFlowM3h != nil {
+		fields["volume_flow_m3h"] = *v.VolumeFlowM3h
+	}
+	if v.PowerKW != nil {
+		fields["power_kw"] = *v.PowerKW
+	}
+}
+
+// aggregate decodes the reported records into their fields, alongside one
+// measurement per decoded value.
+func aggregate(recs []wmbus.Record) (aggregateValues, []driver.Measurement, error) {
 	var out aggregateValues
+	var measurements []driver.Measurement
 	for _, rec := range recs {
-		switch {
-		case rec.VIF == 0x6D:
-			ts, err := wmbus.DecodeTypeFDateTime(rec.Data)
+		if len(rec.VIFE) > 0 {
+			// Combinable extensions change the meaning of the base VIF.
+			continue
+		}
+		if dt, isDate, err := wmbus.RecordDateTime(rec); isDate {
 			if err != nil {
-				return out, err
+				return out, nil, fmt.Errorf("decode VIF 0x%02X: %w", rec.VIF, err)
 			}
-			out.DeviceDateTime = ts.Format("2006-01-02 15:04")
-		case isEnergyVIF(rec.VIF):
-			val, err := decodeValue(rec)
-			if err != nil {
-				return out, err
+			// A meter clock flagged invalid is left out instead of failing
+			// the whole telegram.
+			if dt.Invalid {
+				continue
 			}
-			if rec.Tariff == 1 {
-				out.TotalCoolingKWh = ptr(val)
+			if rec.VIF == 0x6C {
+				out.DueDate = dt.Time.Format(wmbus.DateLayout)
 			} else {
-				out.TotalHeatingKWh = ptr(val)
-			}
-		case isVolumeVIF(rec.VIF):
-			val, err := decodeValue(rec)
-			if err != nil {
-				return out, err
-			}
-			switch {
-			case rec.Subunit == 1:
-				out.C1VolumeM3 = ptr(val)
-			case rec.Subunit == 2:
-				out.C2VolumeM3 = ptr(val)
-			case rec.Tariff == 1:
-				out.TotalCoolingM3 = ptr(val)
-			default:
-				out.TotalHeatingM3 = ptr(val)
-			}
-		case isVolumeFlowVIF(rec.VIF):
-			val, err := decodeValue(rec)
-			if err != nil {
-				return out, err
-			}
-			out.VolumeFlowM3h = ptr(val)
-		case isPowerVIF(rec.VIF):
-			val, err := decodeValue(rec)
-			if err != nil {
-				return out, err
-			}
-			out.PowerKW = ptr(val)
-		case isFlowTempVIF(rec.VIF):
-			val, err := decodeValue(rec)
-			if err != nil {
-				return out, err
+				out.DeviceDateTime = dt.Time.Format(wmbus.RecordLayout(rec))
 			}
-			out.SupplyTempC = ptr(val)
-		case isReturnTempVIF(rec.VIF):
-			val, err := decodeValue(rec)
-			if err != nil {
-				return out, err
-			}
-			out.ReturnTempC = ptr(val)
+			continue
+		}
+		slot, spec := out.slotFor(rec)
+		if slot == nil {
+			continue
+		}
+		val, ok, err := decodeValue(rec, spec.unit)
+		if err != nil {
+			return out, nil, err
+		}
+		if ok {
+			*slot = ptr(val)
+			measurements = append(measurements, driver.NewMeasurement(spec.name, spec.quantity, val, spec.unit, rec))
+		}
+	}
+	return out, measurements, nil
+}
+
+// fieldSpec names a reported field and the quantity and unit of its value.
+type fieldSpec struct {
+	name     string
+	quantity string
+	unit     string
+}
+
+var (
+	fieldHeatingKWh = fieldSpec{"total_heating_kwh", "energy", "kWh"}
+	fieldCoolingKWh = fieldSpec{"total_cooling_kwh", "energy", "kWh"}
+	fieldHeatingM3  = fieldSpec{"total_heating_m3", "volume", "m3"}
+	fieldCoolingM3  = fieldSpec{"total_cooling_m3", "volume", "m3"}
+	fieldC1VolumeM3 = fieldSpec{"c1_volume_m3", "volume", "m3"}
+	fieldC2VolumeM3 = fieldSpec{"c2_volume_m3", "volume", "m3"}
+	fieldFlowM3h    = fieldSpec{"volume_flow_m3h", "volume_flow", "m3/h"}
+	fieldPowerKW    = fieldSpec{"power_kw", "power", "kW"}
+	fieldSupplyC    = fieldSpec{"supply_temperature_c", "flow_temperature", "C"}
+	fieldReturnC    = fieldSpec{"return_temperature_c", "return_temperature", "C"}
+)
+
+// slotFor selects the aggregate field a record feeds, or nil when the
+// record is not reported.
+func (out *aggregateValues) slotFor(rec wmbus.Record) (**float64, fieldSpec) {
+	switch {
+	case isEnergyVIF(rec.VIF):
+		if rec.Tariff == 1 {
+			return &out.TotalCoolingKWh, fieldCoolingKWh
 		}
+		return &out.TotalHeatingKWh, fieldHeatingKWh
+	case isVolumeVIF(rec.VIF):
+		switch {
+		case rec.Subunit == 1:
+			return &out.C1VolumeM3, fieldC1VolumeM3
+		case rec.Subunit == 2:
+			return &out.C2VolumeM3, fieldC2VolumeM3
+		case rec.Tariff == 1:
+			return &out.TotalCoolingM3, fieldCoolingM3
+		default:
+			return &out.TotalHeatingM3, fieldHeatingM3
+		}
+	case isVolumeFlowVIF(rec.VIF):
+		return &out.VolumeFlowM3h, fieldFlowM3h
+	case isPowerVIF(rec.VIF):
+		return &out.PowerKW, fieldPowerKW
+	case isFlowTempVIF(rec.VIF):
+		return &out.SupplyTempC, fieldSupplyC
+	case isReturnTempVIF(rec.VIF):
+		return &out.ReturnTempC, fieldReturnC
+	default:
+		return nil, fieldSpec{}
 	}
-	return out, nil
 }
 
 func isEnergyVIF(v int) bool { return v >= 0x00 && v <= 0x0F }
@@ -195,131 +277,28 @@ func isPowerVIF(v int) bool {
 func isFlowTempVIF(v int) bool   { return v >= 0x58 && v <= 0x5B }
 func isReturnTempVIF(v int) bool { return v >= 0x5C && v <= 0x5F }
 
-type unitKind int
-
-const (
-	unitUnknown unitKind = iota
-	unitKWh
-	unitMJ
-	unitM3
-	unitM3h
-	unitMJh
-	unitKW
-	unitCelsius
-)
-
-func scaleForVIF(v int) (float64, unitKind, bool) {
-	switch v {
-	case 0x00:
-		return 1_000_000, unitKWh, true
-	case 0x01:
-		return 100_000, unitKWh, true
-	case 0x02:
-		return 10_000, unitKWh, true
-	case 0x03:
-		return 1_000, unitKWh, true
-	case 0x04:
-		return 100, unitKWh, true
-	case 0x05:
-		return 10, unitKWh, true
-	case 0x06:
-		return 1, unitKWh, true
-	case 0x07:
-		return 0.1, unitKWh, true
-	case 0x08:
-		return 1_000_000, unitMJ, true
-	case 0x09:
-		return 100_000, unitMJ, true
-	case 0x0A:
-		return 10_000, unitMJ, true
-	case 0x0B:
-		return 1_000, unitMJ, true
-	case 0x10:
-		return 1_000_000, unitM3, true
-	case 0x13:
-		return 1_000, unitM3, true
-	case 0x14:
-		return 100, unitM3, true
-	case 0x15:
-		return 10, unitM3, true
-	case 0x16:
-		return 1, unitM3, true
-	case 0x17:
-		return 0.1, unitM3, true
-	case 0x28:
-		return 1_000_000, unitKW, true
-	case 0x29:
-		return 100_000, unitKW, true
-	case 0x2A:
-		return 10_000, unitKW, true
-	case 0x2B:
-		return 1_000, unitKW, true
-	case 0x2C:
-		return 100, unitKW, true
-	case 0x2D:
-		return 10, unitKW, true
-	case 0x2E:
-		return 1, unitKW, true
-	case 0x2F:
-		return 0.1, unitKW, true
-	case 0x3B:
-		return 1_000, unitM3h, true
-	case 0x3C:
-		return 100, unitM3h, true
-	case 0x3D:
-		return 10, unitM3h, true
-	case 0x3E:
-		return 1, unitM3h, true
-	case 0x40:
-		return 600_000_000, unitM3h, true
-	case 0x48:
-		return 1_000_000_000 * 3600, unitM3h, true
-	case 0x30:
-		return 1_000_000, unitMJh, true
-	case 0x31:
-		return 100_000, unitMJh, true
-	case 0x32:
-		return 10_000, unitMJh, true
-	case 0x59:
-		return 100, unitCelsius, true
-	case 0x5D:
-		return 100, unitCelsius, true
-	default:
-		return 0, unitUnknown, false
-	}
-}
-
-func decodeValue(rec wmbus.Record) (float64, error) {
-	raw, err := wmbus.DecodeBCDLittleEndian(rec.Data)
+// decodeValue returns the value of a record in unit, scaled and converted
+// according to its VIF. The boolean is false when the meter marked the value
+// as invalid or sent no number.
+func decodeValue(rec wmbus.Record, unit string) (float64, bool, error) {
+	raw, err := wmbus.RecordValue(rec)
 	if err != nil {
-		return 0, fmt.Errorf("decode VIF 0x%02X: %w", rec.VIF, err)
+		return 0, false, fmt.Errorf("decode VIF 0x%02X: %w", rec.VIF, err)
 	}
-	scale, unit, ok := scaleForVIF(rec.VIF)
-	if !ok || scale == 0 {
-		return 0, fmt.Errorf("unsupported VIF 0x%02X", rec.VIF)
+	if !raw.Valid() || !raw.Numeric() {
+		return 0, false, nil
 	}
-	value := float64(raw) / scale
-	switch unit {
-	case unitKWh, unitM3, unitM3h, unitCelsius, unitKW:
-		return value, nil
-	case unitMJ:
-		return value / 3.6, nil
-	case unitMJh:
-		return value * (1000.0 / 3.6), nil
-	default:
-		return 0, fmt.Errorf("unsupported unit for VIF 0x%02X", rec.VIF)
+	info, ok := records.DescribeVIF(rec.VIF)
+	if !ok {
+		return 0, false, fmt.Errorf("unsupported VIF 0x%02X", rec.VIF)
+	}
+	value, err := units.Convert(raw.Float()*info.Scale, info.Unit, unit)
+	if err != nil {
+		return 0, false, fmt.Errorf("VIF 0x%02X: %w", rec.VIF, err)
 	}
+	return value, true, nil
 }
 
 func ptr(v float64) *float64 {
 	return &v
 }
-
-func trimToApplication(payload []byte) []byte {
-	for i := 0; i+1 < len(payload); i++ {
-		if payload[i] == 0x04 && payload[i+1] == 0x6D {
-			return payload[i:]
-		}
-	}
-	return payload
-}
@@ -0,0 +1,40 @@
+package hydrocalm4
+
+import (
+	"math"
+	"testing"
+
+	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
+)
+
+func TestDecodeValueUnits(t *testing.T) {
+	cases := []struct {
+		name string
+		vif  int
+		unit string
+		want float64
+	}{
+		// 3600 MJ/h (VIF 0x36: 10^6 J/h) is 1000 kW.
+		{"power MJ/h", 0x36, "kW", 1000},
+		{"power W", 0x2B, "kW", 3.6},
+		{"energy MJ", 0x0E, "kWh", 1000},
+		// 3600 * 10^-7 m3/min is 0.0216 m3/h.
+		{"flow m3/min", 0x40, "m3/h", 0.0216},
+		// 3600 * 10^-9 m3/s is 0.01296 m3/h.
+		{"flow m3/s", 0x48, "m3/h", 0.01296},
+		{"temperature", 0x59, "C", 36},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// DIF 0x04: 32-bit integer 3600.
+			rec := wmbus.Record{DIF: 0x04, VIF: tc.vif, Data: []byte{0x10, 0x0E, 0x00, 0x00}}
+			got, ok, err := decodeValue(rec, tc.unit)
+			if err != nil || !ok {
+				t.Fatalf("decodeValue: %v, %v", ok, err)
+			}
+			if math.Abs(got-tc.want) > 1e-9*math.Max(1, math.Abs(tc.want)) {
+				t.Fatalf("got %v %s, want %v", got, tc.unit, tc.want)
+			}
+		})
+	}
+}
@@ -5,15 +5,16 @@ import (
 	"fmt"
 
 	"github.com/d21d3q/gowmbus/internal/driver"
+	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
 	"github.com/d21d3q/gowmbus/internal/frame"
+	"github.com/d21d3q/gowmbus/internal/options"
+	"github.com/d21d3q/gowmbus/internal/records"
 )
 
 const (
-	manufacturerBMT      = 0x09B4
 	ciHydrodigitPrimary  = 0x7A
+	ciHydrodigitLong     = 0x72
 	ciHydrodigitExtended = 0x8C
-	defaultTimestamp     = "1111-11-11T11:11:11Z"
-	dateTimeFormat       = "2006-01-02 15:04"
 	deviceTypeWater      = 0x07
 	deviceTypeWarmWater  = 0x06
 )
@@ -23,12 +24,19 @@ var monthOrder = []string{
 	"July", "August", "September", "October", "November", "December",
 }
 
+var manufacturerBMT = frame.MustManufacturerID("BMT")
+
 func init() {
 	driver.Register(driver.Detection{
 		Manufacturer: manufacturerBMT,
 		CI:           ciHydrodigitPrimary,
 		DeviceTypes:  []byte{deviceTypeWater, deviceTypeWarmWater},
 	}, Driver{})
+	driver.Register(driver.Detection{
+		Manufacturer: manufacturerBMT,
+		CI:           ciHydrodigitLong,
+		DeviceTypes:  []byte{deviceTypeWater, deviceTypeWarmWater},
+	}, Driver{})
 	driver.Register(driver.Detection{
 		Manufacturer: manufacturerBMT,
 		CI:           ciHydrodigitExtended,
@@ -50,42 +58,64 @@ func (Driver) PartialFields(t *frame.Telegram) map[string]any {
 		"_":     "telegram",
 		"id":    t.MeterIDString(),
 		"meter": "hydrodigit",
-		"media": mediaFromDeviceType(t.DeviceType),
+		"media": t.Media(),
 	}
-	for k, v := range t.StatusFlags {
+	for k, v := range legacyStatusFlags(t.Status) {
 		fields[k] = v
 	}
 	return fields
 }
 
 // Process extracts manufacturer-specific data and returns a response map.
-func (Driver) Process(_ context.Context, t *frame.Telegram) (map[string]any, error) {
-	readings, mfctPayload, err := parseStandardReadings(t.Payload)
+func (d Driver) Process(ctx context.Context, t *frame.Telegram) (map[string]any, error) {
+	report, err := d.Report(ctx, t)
 	if err != nil {
 		return nil, err
 	}
+	return report.Fields, nil
+}
+
+var _ driver.Reporter = Driver{}
+
+// Report decodes the current readings and the manufacturer data, and the
+// volume stored for each historic storage slot. The total volume records and
+// the battery voltage and backflow volume of the legacy block are also
+// reported as measurements.
+func (Driver) Report(ctx context.Context, t *frame.Telegram) (driver.Report, error) {
+	recs, err := records.Decode(t.Payload)
+	if err != nil {
+		return driver.Report{}, err
+	}
+	current, slots := wmbus.SplitStorage(recs)
+	readings, mfctPayload, err := parseReadings(current)
+	if err != nil {
+		return driver.Report{}, err
+	}
 	if readings.TotalVolumeM3 == 0 && readings.MeterDateTime.IsZero() {
-		return nil, fmt.Errorf("hydrodigit: telegram appears encrypted (supply meter key)")
+		return driver.Report{}, fmt.Errorf("hydrodigit: telegram appears encrypted (supply meter key)")
 	}
 	if len(mfctPayload) == 0 {
-		return nil, fmt.Errorf("hydrodigit manufacturer data missing")
+		return driver.Report{}, fmt.Errorf("hydrodigit manufacturer data missing")
 	}
 	mfct, err := ParseManufacturerData(mfctPayload, readings.VolumeScale)
 	if err != nil {
-		return nil, err
+		return driver.Report{}, err
 	}
 	fields := map[string]any{
 		"_":         "telegram",
 		"id":        t.MeterIDString(),
 		"meter":     "hydrodigit",
-		"media":     mediaFromDeviceType(t.DeviceType),
-		"timestamp": defaultTimestamp,
+		"media":     t.Media(),
+		"timestamp": options.Timestamp(ctx),
 	}
 	if readings.TotalVolumeM3 > 0 {
 		fields["total_m3"] = readings.TotalVolumeM3
 	}
 	if !readings.MeterDateTime.IsZero() {
-		fields["meter_datetime"] = readings.MeterDateTime.Format(dateTimeFormat)
+		fields["meter_datetime"] = readings.MeterDateTime.Format(wmbus.DateTimeLayout)
+	}
+	if !readings.DueDate.IsZero() {
+		fields["due_date"] = readings.DueDate.Format(wmbus.DateLayout)
 	}
 
 	if mfct.Contents != "" {
@@ -109,25 +139,32 @@ func (Driver) Process(_ context.Context, t *frame.Telegram) (map[string]any, err
 	if mfct.Variant == "extended" {
 		populateExtendedFields(fields, mfct)
 	}
-	for k, v := range t.StatusFlags {
+	flags := legacyStatusFlags(t.Status)
+	for k, v := range flags {
 		fields[k] = v
 	}
-	if t.StatusFlags["status_perm_alarm"] {
+	if flags["status_perm_alarm"] {
 		fields["alarm_tamper"] = true
 	}
 
-	return fields, nil
-}
-
-func mediaFromDeviceType(device byte) string {
-	switch device {
-	case deviceTypeWater:
-		return "water"
-	case deviceTypeWarmWater:
-		return "warm water"
-	default:
-		return "unknown"
-	}
+	var measurements []driver.Measurement
+	if volume, ok := readings.volumeMeasurement(); ok {
+		measurements = append(measurements, volume)
+	}
+	// The legacy block always carries both values, zero included.
+	if mfct.Variant == "legacy" {
+		measurements = append(measurements,
+			driver.Measurement{Name: "voltage_v", Quantity: "voltage", Value: mfct.Voltage, Unit: "V"},
+			driver.Measurement{Name: "backflow_m3", Quantity: "volume", Value: mfct.BackflowM3, Unit: "m3"},
+		)
+	}
+	history, historic, historyErr := parseHistory(slots)
+	return driver.Report{
+		Fields:       fields,
+		History:      history,
+		HistoryErr:   historyErr,
+		Measurements: append(measurements, historic...),
+	}, nil
 }
 
 func populateExtendedFields(fields map[string]any, data Data) {
@@ -149,11 +186,24 @@ func populateExtendedFields(fields map[string]any, data Data) {
 	}
 }
 
+var _ driver.StatusNamer = Driver{}
+
+// StatusBits names the manufacturer-specific status bits.
+func (Driver) StatusBits() frame.StatusBits {
+	return frame.StatusBits{
+		0x80: "empty_pipe",
+		0x40: "reverse_flow",
+		0x20: "freezing",
+	}
+}
+
 type statusFlag struct {
 	mask  byte
 	field string
 }
 
+// linkStatusFlags keeps the field names hydrodigit has always reported for
+// the status byte, including the standard bits 2-4.
 var linkStatusFlags = []statusFlag{
 	{0x80, "status_empty_pipe"},
 	{0x40, "status_reverse_flow"},
@@ -163,3 +213,13 @@ var linkStatusFlags = []statusFlag{
 	{0x04, "status_battery_alarm"},
 	{0x02, "status_hw_alarm"},
 }
+
+func legacyStatusFlags(status frame.Status) map[string]bool {
+	flags := make(map[string]bool)
+	for _, def := range linkStatusFlags {
+		if byte(status)&def.mask != 0 {
+			flags[def.field] = true
+		}
+	}
+	return flags
+}
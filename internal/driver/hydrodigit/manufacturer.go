@@ -4,6 +4,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+
+	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
 )
 
 // Data captures the decoded contents of the hydrodigit manufacturer-specific
@@ -292,18 +294,15 @@ func clampBattery(raw byte) uint8 {
 	return raw
 }
 
+// decodeBCDDate reports the date as text and how many bytes the optional
+// section occupies, even when the digits are invalid.
 func decodeBCDDate(b []byte) (string, int, error) {
-	if len(b) < 3 {
-		return "", 0, errors.New("BCD date requires 3 bytes")
-	}
-	bytes := b[:3]
-	for _, by := range bytes {
-		if (by&0x0F) > 9 || (by>>4) > 9 {
-			return "", 3, fmt.Errorf("invalid BCD digit in %02X", by)
+	date, err := wmbus.DecodeBCDDate(b)
+	if err != nil {
+		if len(b) < 3 {
+			return "", 0, err
 		}
+		return "", 3, err
 	}
-	year := int(bytes[0]&0x0F) + int(bytes[0]>>4)*10
-	month := int(bytes[1]&0x0F) + int(bytes[1]>>4)*10
-	day := int(bytes[2]&0x0F) + int(bytes[2]>>4)*10
-	return fmt.Sprintf("20%02d-%02d-%02d", year, month, day), 3, nil
+	return date.String(), 3, nil
 }
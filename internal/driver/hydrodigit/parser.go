@@ -1,91 +1,123 @@
 package hydrodigit
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/d21d3q/gowmbus/internal/driver"
 	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
+	"github.com/d21d3q/gowmbus/internal/records"
 )
 
 type standardReadings struct {
 	TotalVolumeM3 float64
+	// VolumeRecord is the record TotalVolumeM3 was taken from; HasVolume is
+	// false when no valid volume record was found.
+	VolumeRecord  records.Record
+	HasVolume     bool
 	MeterDateTime time.Time
+	DueDate       time.Time
 	VolumeScale   float64
 }
 
 func parseStandardReadings(payload []byte) (standardReadings, []byte, error) {
+	recs, err := records.Decode(payload)
+	if err != nil {
+		return standardReadings{}, nil, err
+	}
+	// Historic storage slots must not shadow the current readings.
+	current, _ := wmbus.SplitStorage(recs)
+	return parseReadings(current)
+}
+
+// parseReadings collects the readings of one storage slot. The first
+// non-zero volume wins; a zero volume is replaced by a later record.
+func parseReadings(recs []records.Record) (standardReadings, []byte, error) {
 	var readings standardReadings
 	var manufacturerBlock []byte
-	i := 0
-	for i < len(payload) {
-		dif := payload[i]
-		i++
-		if dif == 0x2F {
-			continue
-		}
-		if dif == 0x0F {
-			if i <= len(payload) {
-				manufacturerBlock = payload[i-1:]
+	for _, rec := range recs {
+		switch {
+		case rec.IsManufacturerData():
+			// Normalise to the DIF 0x0F form ParseManufacturerData expects.
+			manufacturerBlock = append([]byte{0x0F}, rec.Data...)
+		case isDateRecord(rec):
+			dt, _, err := wmbus.RecordDateTime(rec)
+			if err != nil {
+				return readings, nil, err
 			}
-			break
-		}
-		dataDIF := dif
-		for (dif & 0x80) != 0 {
-			if i >= len(payload) {
-				return readings, nil, fmt.Errorf("payload ended while reading DIFE bytes")
+			// A meter clock flagged invalid is left out instead of failing
+			// the whole telegram.
+			if dt.Invalid {
+				continue
 			}
-			dife := payload[i]
-			i++
-			dif = dife
-			if (dife & 0x80) == 0 {
-				break
+			switch {
+			case rec.VIF == 0x6C && readings.DueDate.IsZero():
+				readings.DueDate = dt.Time
+			case rec.VIF == 0x6D && readings.MeterDateTime.IsZero():
+				readings.MeterDateTime = dt.Time
 			}
-		}
-		if i >= len(payload) {
-			return readings, nil, fmt.Errorf("payload ended before VIF")
-		}
-		vif := payload[i]
-		i++
-		for (vif & 0x80) != 0 {
-			if i >= len(payload) {
-				return readings, nil, fmt.Errorf("payload ended while reading VIFE bytes")
+		case readings.TotalVolumeM3 == 0 && len(rec.VIFE) == 0:
+			scale, ok := volumeScaleFromVIF(rec.VIF)
+			if !ok {
+				continue
 			}
-			vif = payload[i]
-			i++
-		}
-		length, ok := wmbus.LengthForDIF(dataDIF & 0x0F)
-		if !ok {
-			return readings, nil, fmt.Errorf("unsupported DIF 0x%02X", dataDIF)
-		}
-		if i+length > len(payload) {
-			return readings, nil, fmt.Errorf("payload truncated for DIF 0x%02X", dataDIF)
-		}
-		data := payload[i : i+length]
-		i += length
-
-		switch {
-		case (dataDIF&0x0F) == 0x0C && readings.TotalVolumeM3 == 0:
-			digits, err := wmbus.DecodeBCDLittleEndian(data)
+			value, err := wmbus.RecordValue(rec)
 			if err != nil {
 				return readings, nil, err
 			}
-			if scale, ok := volumeScaleFromVIF(vif); ok {
-				readings.TotalVolumeM3 = float64(digits) * scale
+			if value.Valid() && value.Numeric() {
+				readings.TotalVolumeM3 = value.Float() * scale
 				readings.VolumeScale = scale
+				readings.VolumeRecord = rec
+				readings.HasVolume = true
 			}
-		case (dataDIF&0x0F) == 0x04 && vif == 0x6D && readings.MeterDateTime.IsZero():
-			ts, err := wmbus.DecodeTypeFDateTime(data)
-			if err != nil {
-				return readings, nil, err
-			}
-			readings.MeterDateTime = ts
 		}
 	}
 	return readings, manufacturerBlock, nil
 }
 
-func volumeScaleFromVIF(vif byte) (float64, bool) {
-	switch vif & 0x7F {
+func isDateRecord(rec records.Record) bool {
+	_, ok, _ := wmbus.RecordDateTime(rec)
+	return ok
+}
+
+// volumeMeasurement describes the total volume of r, if it has one.
+func (r standardReadings) volumeMeasurement() (driver.Measurement, bool) {
+	if !r.HasVolume {
+		return driver.Measurement{}, false
+	}
+	return driver.NewMeasurement("total_m3", "volume", r.TotalVolumeM3, "m3", r.VolumeRecord), true
+}
+
+// parseHistory reports the volume of each historic storage slot, picked as
+// for the current readings, along with its measurement. A slot that fails
+// to decode is left out and reported in the error.
+func parseHistory(slots []wmbus.StorageSlot) ([]driver.History, []driver.Measurement, error) {
+	history := make([]driver.History, 0, len(slots))
+	var measurements []driver.Measurement
+	var errs []error
+	for _, slot := range slots {
+		readings, _, err := parseReadings(slot.Records)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("storage %d: %w", slot.Storage, err))
+			continue
+		}
+		entry := driver.History{Storage: slot.Storage, Fields: map[string]any{}}
+		if slot.HasDate && !slot.Date.Invalid {
+			entry.Date = slot.Date.Time
+		}
+		if volume, ok := readings.volumeMeasurement(); ok {
+			entry.Fields["total_m3"] = readings.TotalVolumeM3
+			measurements = append(measurements, volume)
+		}
+		history = append(history, entry)
+	}
+	return history, measurements, errors.Join(errs...)
+}
+
+func volumeScaleFromVIF(vif int) (float64, bool) {
+	switch vif {
 	case 0x10:
 		return 1e-6, true // cm^3
 	case 0x11:
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/d21d3q/gowmbus/internal/frame"
+	"github.com/d21d3q/gowmbus/internal/records"
+)
+
+// Measurement is a numeric reading with an explicit unit. Name is the key
+// the value is reported under in the driver's field map; historic values
+// reuse the key of the current value and differ in Storage.
+type Measurement struct {
+	Name string
+	// Quantity names what is measured, using the VIF catalogue names such
+	// as energy, volume or flow_temperature.
+	Quantity string
+	Value    float64
+	// Unit is the unit of Value after any conversion the driver applied,
+	// e.g. kWh for an energy record the meter sent in MJ.
+	Unit     string
+	Storage  int
+	Tariff   int
+	Subunit  int
+	Function int
+	// Record is the data record the value was decoded from, or nil for
+	// values taken from manufacturer-specific data.
+	Record *records.Record
+}
+
+// MeasurementReporter is implemented by drivers that describe their numeric
+// fields, current and historic, as measurements. Measurements is only called
+// after Process succeeded. The measurements of a historic slot that fails to
+// decode are left out and reported in the error next to the others.
+type MeasurementReporter interface {
+	Measurements(context.Context, *frame.Telegram) ([]Measurement, error)
+}
+
+// NewMeasurement builds a measurement for rec, copying its storage, tariff,
+// subunit and function.
+func NewMeasurement(name, quantity string, value float64, unit string, rec records.Record) Measurement {
+	return Measurement{
+		Name:     name,
+		Quantity: quantity,
+		Value:    value,
+		Unit:     unit,
+		Storage:  rec.Storage,
+		Tariff:   rec.Tariff,
+		Subunit:  rec.Subunit,
+		Function: rec.Function,
+		Record:   &rec,
+	}
+}
@@ -3,7 +3,9 @@ package driver
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/d21d3q/gowmbus/internal/frame"
 )
@@ -13,6 +15,20 @@ type Detection struct {
 	Manufacturer uint16
 	CI           byte
 	DeviceTypes  []byte
+	// Versions restricts the match to meter versions within any of the
+	// ranges. An empty list accepts every version.
+	Versions []VersionRange
+}
+
+// VersionRange is an inclusive range of meter version bytes.
+type VersionRange struct {
+	Min byte
+	Max byte
+}
+
+// Contains reports whether v lies within the range.
+func (r VersionRange) Contains(v byte) bool {
+	return v >= r.Min && v <= r.Max
 }
 
 // Driver processes telegrams once selected.
@@ -26,44 +42,222 @@ type PartialReporter interface {
 	PartialFields(*frame.Telegram) map[string]any
 }
 
+// DefaultPriority is the priority used by Register.
+const DefaultPriority = 0
+
+// StatusNamer names the manufacturer-specific bits (5-7) of the transport
+// status byte. The telegram's StatusFlags are rebuilt with these names
+// before Process or PartialFields is called.
+type StatusNamer interface {
+	StatusBits() frame.StatusBits
+}
+
+// ApplyStatusNames rebuilds t.StatusFlags with the names supplied by drv, if
+// it implements StatusNamer.
+func ApplyStatusNames(drv Driver, t *frame.Telegram) {
+	if namer, ok := drv.(StatusNamer); ok {
+		t.StatusFlags = t.Status.Flags(namer.StatusBits())
+	}
+}
+
+// History holds the values of one historic storage slot.
+type History struct {
+	Storage int
+	// Date comes from the slot's own date record and is zero when the meter
+	// sent none.
+	Date   time.Time
+	Fields map[string]any
+}
+
+// HistoryReporter is implemented by drivers that report historic storage
+// slots (storage number 1 and up) apart from the current values returned by
+// Process. History is only called after Process succeeded. A slot that fails
+// to decode is left out and reported in the error next to the other slots.
+type HistoryReporter interface {
+	History(context.Context, *frame.Telegram) ([]History, error)
+}
+
 var (
 	regMu    sync.RWMutex
 	registry []registeredDriver
+	fallback Driver
 )
 
 type registeredDriver struct {
-	detect Detection
-	driver Driver
+	detect   Detection
+	driver   Driver
+	priority int
 }
 
-// Register stores a driver/detection pair in memory.
+// Register stores a driver/detection pair with the default priority.
 func Register(det Detection, drv Driver) {
+	RegisterPriority(det, drv, DefaultPriority)
+}
+
+// RegisterPriority stores a driver/detection pair. When several detections
+// match a telegram, the one with the highest priority wins; ties are broken
+// by driver name so the outcome does not depend on package init order.
+func RegisterPriority(det Detection, drv Driver, priority int) {
+	regMu.Lock()
+	defer regMu.Unlock()
+	registry = append(registry, registeredDriver{detect: det, driver: drv, priority: priority})
+	sortDrivers(registry)
+}
+
+// Unregister removes every detection registered for drv. Drivers are
+// compared with ==, so drv must be the comparable value that was registered,
+// such as the same pointer.
+func Unregister(drv Driver) {
+	regMu.Lock()
+	defer regMu.Unlock()
+	kept := make([]registeredDriver, 0, len(registry))
+	for _, rd := range registry {
+		if rd.driver != drv {
+			kept = append(kept, rd)
+		}
+	}
+	registry = kept
+}
+
+// sortDrivers orders drivers by priority, highest first, breaking ties by
+// name so the outcome does not depend on registration order.
+func sortDrivers(drivers []registeredDriver) {
+	sort.SliceStable(drivers, func(i, j int) bool {
+		if drivers[i].priority != drivers[j].priority {
+			return drivers[i].priority > drivers[j].priority
+		}
+		return drivers[i].driver.Name() < drivers[j].driver.Name()
+	})
+}
+
+// RegisterFallback installs the driver used when no registered detection
+// matches. It runs after every other driver regardless of its priority.
+func RegisterFallback(drv Driver) {
 	regMu.Lock()
 	defer regMu.Unlock()
-	registry = append(registry, registeredDriver{detect: det, driver: drv})
+	fallback = drv
 }
 
-// Lookup returns the first driver that matches the telegram metadata.
+// Lookup returns the highest priority driver that matches the telegram
+// metadata, or the fallback driver when none does.
 func Lookup(t *frame.Telegram) (Driver, error) {
+	return LookupWith(nil, t)
+}
+
+// Candidate is a driver and detection kept outside the global registry,
+// e.g. a declarative driver loaded for a single call.
+type Candidate struct {
+	Detection Detection
+	Driver    Driver
+	Priority  int
+}
+
+// LookupWith is Lookup with extra candidates ranked alongside the registered
+// drivers by the same priority and name order.
+func LookupWith(extra []Candidate, t *frame.Telegram) (Driver, error) {
+	regMu.RLock()
+	candidates := make([]registeredDriver, 0, len(registry)+len(extra))
+	candidates = append(candidates, registry...)
+	fb := fallback
+	regMu.RUnlock()
+	if len(extra) > 0 {
+		for _, c := range extra {
+			candidates = append(candidates, registeredDriver{detect: c.Detection, driver: c.Driver, priority: c.Priority})
+		}
+		sortDrivers(candidates)
+	}
+	for _, rd := range candidates {
+		if matches(rd.detect, t) {
+			return rd.driver, nil
+		}
+	}
+	if fb != nil {
+		return fb, nil
+	}
+	return nil, fmt.Errorf("driver not found for manufacturer 0x%04X CI 0x%02X", t.Identity().Manufacturer, t.CI)
+}
+
+// ByName returns the registered driver with the given name, including the
+// fallback driver.
+func ByName(name string) (Driver, error) {
+	return ByNameWith(nil, name)
+}
+
+// ByNameWith is ByName that also considers the extra candidates first.
+func ByNameWith(extra []Candidate, name string) (Driver, error) {
+	for _, c := range extra {
+		if c.Driver.Name() == name {
+			return c.Driver, nil
+		}
+	}
 	regMu.RLock()
 	defer regMu.RUnlock()
 	for _, rd := range registry {
-		if matches(rd.detect, t) {
+		if rd.driver.Name() == name {
 			return rd.driver, nil
 		}
 	}
-	return nil, fmt.Errorf("driver not found for manufacturer 0x%04X CI 0x%02X", t.Manufacturer, t.CI)
+	if fallback != nil && fallback.Name() == name {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("driver %q is not registered", name)
 }
 
+// Registration describes a registered detection rule.
+type Registration struct {
+	Name      string
+	Detection Detection
+	Priority  int
+	// Fallback marks the driver used when no detection matches.
+	Fallback bool
+}
+
+// Registrations lists every registered detection in lookup order, followed
+// by the fallback driver if one is installed.
+func Registrations() []Registration {
+	regMu.RLock()
+	defer regMu.RUnlock()
+	out := make([]Registration, 0, len(registry)+1)
+	for _, rd := range registry {
+		out = append(out, Registration{Name: rd.driver.Name(), Detection: rd.detect, Priority: rd.priority})
+	}
+	if fallback != nil {
+		out = append(out, Registration{Name: fallback.Name(), Fallback: true})
+	}
+	return out
+}
+
+// matches compares the detection rule against the meter identity, which
+// prefers the long transport header over the link-layer address. The CI may
+// name either the link CI or the CI chained behind an Extended Link Layer.
 func matches(det Detection, t *frame.Telegram) bool {
-	if det.Manufacturer != t.Manufacturer || det.CI != t.CI {
+	id := t.Identity()
+	if det.Manufacturer != id.Manufacturer {
+		return false
+	}
+	if det.CI != t.CI && det.CI != t.AppCI {
+		return false
+	}
+	if !matchesVersion(det.Versions, id.Version) {
 		return false
 	}
 	if len(det.DeviceTypes) == 0 {
 		return true
 	}
 	for _, dt := range det.DeviceTypes {
-		if dt == t.DeviceType {
+		if dt == id.DeviceType {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesVersion(ranges []VersionRange, version byte) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if r.Contains(version) {
 			return true
 		}
 	}
@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/d21d3q/gowmbus/internal/frame"
+)
+
+type namedDriver string
+
+func (d namedDriver) Name() string { return string(d) }
+
+func (namedDriver) Process(context.Context, *frame.Telegram) (map[string]any, error) {
+	return nil, nil
+}
+
+func withRegistry(t *testing.T) {
+	t.Helper()
+	saved, savedFallback := registry, fallback
+	registry, fallback = nil, nil
+	t.Cleanup(func() { registry, fallback = saved, savedFallback })
+}
+
+func testTelegram(version byte) *frame.Telegram {
+	return &frame.Telegram{Manufacturer: 0x09B4, Version: version, DeviceType: 0x07, CI: 0x7A}
+}
+
+func TestLookupPriority(t *testing.T) {
+	withRegistry(t)
+	det := Detection{Manufacturer: 0x09B4, CI: 0x7A}
+	Register(det, namedDriver("zeta"))
+	Register(det, namedDriver("alpha"))
+	RegisterPriority(det, namedDriver("preferred"), 10)
+
+	drv, err := Lookup(testTelegram(0x13))
+	if err != nil || drv.Name() != "preferred" {
+		t.Fatalf("expected preferred driver, got %v (%v)", drv, err)
+	}
+	registry = registry[1:]
+	if drv, _ = Lookup(testTelegram(0x13)); drv.Name() != "alpha" {
+		t.Fatalf("expected name order to break ties, got %s", drv.Name())
+	}
+}
+
+func TestLookupWith(t *testing.T) {
+	withRegistry(t)
+	det := Detection{Manufacturer: 0x09B4, CI: 0x7A}
+	Register(det, namedDriver("global"))
+	extra := []Candidate{{Detection: det, Driver: namedDriver("local"), Priority: 5}}
+
+	if drv, _ := LookupWith(extra, testTelegram(0x13)); drv.Name() != "local" {
+		t.Fatalf("expected higher priority local driver, got %s", drv.Name())
+	}
+	if drv, _ := Lookup(testTelegram(0x13)); drv.Name() != "global" {
+		t.Fatalf("local candidate leaked into the registry, got %s", drv.Name())
+	}
+	if _, err := ByName("local"); err == nil {
+		t.Fatalf("local candidate found by name without being passed")
+	}
+	if drv, err := ByNameWith(extra, "local"); err != nil || drv.Name() != "local" {
+		t.Fatalf("ByNameWith: got %v (%v)", drv, err)
+	}
+}
+
+func TestLookupVersionRange(t *testing.T) {
+	withRegistry(t)
+	Register(Detection{
+		Manufacturer: 0x09B4,
+		CI:           0x7A,
+		Versions:     []VersionRange{{Min: 0x10, Max: 0x1F}},
+	}, namedDriver("new"))
+	RegisterFallback(namedDriver("fallback"))
+
+	if drv, _ := Lookup(testTelegram(0x13)); drv.Name() != "new" {
+		t.Fatalf("expected version match, got %s", drv.Name())
+	}
+	if drv, _ := Lookup(testTelegram(0x05)); drv.Name() != "fallback" {
+		t.Fatalf("expected fallback for version 0x05, got %s", drv.Name())
+	}
+}
+
+func TestByName(t *testing.T) {
+	withRegistry(t)
+	Register(Detection{Manufacturer: 0x09B4, CI: 0x7A}, namedDriver("meter"))
+	RegisterFallback(namedDriver("fallback"))
+	for _, name := range []string{"meter", "fallback"} {
+		if drv, err := ByName(name); err != nil || drv.Name() != name {
+			t.Fatalf("ByName(%q) = %v, %v", name, drv, err)
+		}
+	}
+	if _, err := ByName("missing"); err == nil {
+		t.Fatalf("expected error for unknown driver")
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	withRegistry(t)
+	det := Detection{Manufacturer: 0x09B4, CI: 0x7A}
+	Register(det, namedDriver("keep"))
+	RegisterPriority(det, namedDriver("drop"), 10)
+	Unregister(namedDriver("drop"))
+
+	drv, err := Lookup(testTelegram(0x13))
+	if err != nil || drv.Name() != "keep" {
+		t.Fatalf("expected keep driver, got %v (%v)", drv, err)
+	}
+}
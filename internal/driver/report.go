@@ -0,0 +1,26 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/d21d3q/gowmbus/internal/frame"
+)
+
+// Report is everything a driver decodes from one telegram: the fields
+// Process returns, the historic storage slots and the measurements of both.
+type Report struct {
+	Fields  map[string]any
+	History []History
+	// HistoryErr reports the historic slots that failed to decode. They are
+	// missing from History and Measurements; the other slots are kept.
+	HistoryErr   error
+	Measurements []Measurement
+}
+
+// Reporter is implemented by drivers that build their fields, history and
+// measurements in a single pass over the records, so the three always agree.
+// It takes the place of Process, HistoryReporter and MeasurementReporter.
+// An error fails the telegram just as an error from Process does.
+type Reporter interface {
+	Report(context.Context, *frame.Telegram) (Report, error)
+}
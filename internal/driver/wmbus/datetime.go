@@ -0,0 +1,255 @@
+package wmbus
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateTime is a decoded EN 13757-3 date or date/time (types F, G and I).
+type DateTime struct {
+	Time time.Time
+	// SummerTime reports the SU flag (types F and I).
+	SummerTime bool
+	// Invalid reports the IV flag of types F and I, or an all-zero or
+	// all-ones type G date. Time is zero when set.
+	Invalid bool
+}
+
+// Date is a calendar date whose month or day may be zero, as sent by meters
+// for "no event recorded".
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// String formats the date as YYYY-MM-DD, keeping zero fields.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// DST is a decoded type K daylight saving rule.
+type DST struct {
+	BeginMonth int
+	BeginDay   int
+	BeginHour  int
+	EndMonth   int
+	EndDay     int
+	// Deviation is the offset applied during summer time.
+	Deviation time.Duration
+}
+
+// DecodeTypeFDateTime decodes the four-byte Type F timestamp used by many
+// Wireless M-Bus meters.
+func DecodeTypeFDateTime(b []byte) (time.Time, error) {
+	dt, err := DecodeTypeF(b)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if dt.Invalid {
+		return time.Time{}, fmt.Errorf("type F datetime marked invalid: %X", b)
+	}
+	return dt.Time, nil
+}
+
+// DecodeTypeF decodes the four-byte type F date and time (minute
+// resolution). Years are counted from 2000.
+func DecodeTypeF(b []byte) (DateTime, error) {
+	if len(b) != 4 {
+		return DateTime{}, fmt.Errorf("type F datetime requires 4 bytes, got %d", len(b))
+	}
+	dt := DateTime{
+		SummerTime: b[1]&0x80 != 0,
+		Invalid:    b[0]&0x80 != 0,
+	}
+	if dt.Invalid {
+		return dt, nil
+	}
+	minute := int(b[0] & 0x3F)
+	hour := int(b[1] & 0x1F)
+	day := int(b[2] & 0x1F)
+	month := int(b[3] & 0x0F)
+	year := 2000 + int((b[3]&0xF0)>>1|(b[2]&0xE0)>>5)
+	if minute > 59 || hour > 23 || day == 0 || day > 31 || month == 0 || month > 12 {
+		return DateTime{}, fmt.Errorf("invalid type F datetime encoding: %02X%02X%02X%02X", b[0], b[1], b[2], b[3])
+	}
+	dt.Time = time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.UTC)
+	return dt, nil
+}
+
+// DecodeTypeG decodes the two-byte type G date (VIF 0x6C).
+func DecodeTypeG(b []byte) (DateTime, error) {
+	if len(b) != 2 {
+		return DateTime{}, fmt.Errorf("type G date requires 2 bytes, got %d", len(b))
+	}
+	if (b[0] == 0 && b[1] == 0) || (b[0] == 0xFF && b[1] == 0xFF) {
+		return DateTime{Invalid: true}, nil
+	}
+	day := int(b[0] & 0x1F)
+	month := int(b[1] & 0x0F)
+	year := 2000 + int((b[1]&0xF0)>>1|(b[0]&0xE0)>>5)
+	if day == 0 || day > 31 || month == 0 || month > 12 {
+		return DateTime{}, fmt.Errorf("invalid type G date encoding: %02X%02X", b[0], b[1])
+	}
+	return DateTime{Time: time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+// DecodeTypeI decodes the six-byte type I date and time with seconds. The
+// day of week, week number and leap year fields are redundant and ignored.
+func DecodeTypeI(b []byte) (DateTime, error) {
+	if len(b) != 6 {
+		return DateTime{}, fmt.Errorf("type I datetime requires 6 bytes, got %d", len(b))
+	}
+	dt := DateTime{
+		SummerTime: b[1]&0x40 != 0,
+		Invalid:    b[1]&0x80 != 0,
+	}
+	if dt.Invalid {
+		return dt, nil
+	}
+	second := int(b[0] & 0x3F)
+	minute := int(b[1] & 0x3F)
+	hour := int(b[2] & 0x1F)
+	day := int(b[3] & 0x1F)
+	month := int(b[4] & 0x0F)
+	year := 2000 + int((b[4]&0xF0)>>1|(b[3]&0xE0)>>5)
+	if second > 59 || minute > 59 || hour > 23 || day == 0 || day > 31 || month == 0 || month > 12 {
+		return DateTime{}, fmt.Errorf("invalid type I datetime encoding: %X", b)
+	}
+	dt.Time = time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+	return dt, nil
+}
+
+// DecodeTypeJ decodes the three-byte type J time of day into the duration
+// since midnight.
+func DecodeTypeJ(b []byte) (time.Duration, error) {
+	if len(b) != 3 {
+		return 0, fmt.Errorf("type J time requires 3 bytes, got %d", len(b))
+	}
+	second := int(b[0] & 0x3F)
+	minute := int(b[1] & 0x3F)
+	hour := int(b[2] & 0x1F)
+	if second > 59 || minute > 59 || hour > 23 {
+		return 0, fmt.Errorf("invalid type J time encoding: %X", b)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second, nil
+}
+
+// DecodeTypeK decodes the four-byte type K daylight saving rule: begin hour
+// (bits 0-4) with the deviation in hours (bits 5-6, bit 7 negative), begin
+// day, begin and end month (low and high nibble), end day.
+func DecodeTypeK(b []byte) (DST, error) {
+	if len(b) != 4 {
+		return DST{}, fmt.Errorf("type K DST requires 4 bytes, got %d", len(b))
+	}
+	dst := DST{
+		BeginHour:  int(b[0] & 0x1F),
+		BeginDay:   int(b[1] & 0x1F),
+		BeginMonth: int(b[2] & 0x0F),
+		EndMonth:   int(b[2] >> 4),
+		EndDay:     int(b[3] & 0x1F),
+	}
+	deviation := time.Duration(b[0]>>5&0x03) * time.Hour
+	if b[0]&0x80 != 0 {
+		deviation = -deviation
+	}
+	dst.Deviation = deviation
+	if dst.BeginHour > 23 || dst.BeginMonth > 12 || dst.EndMonth > 12 {
+		return DST{}, fmt.Errorf("invalid type K DST encoding: %X", b)
+	}
+	return dst, nil
+}
+
+var typeMUnits = [4]time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour}
+
+// DecodeTypeM decodes a type M lifetime: the low two bits of the first byte
+// select seconds, minutes, hours or days (as in the duration VIFs) and the
+// remaining bytes hold the little-endian count.
+func DecodeTypeM(b []byte) (time.Duration, error) {
+	if len(b) < 2 || len(b) > 8 {
+		return 0, fmt.Errorf("type M lifetime requires 2 to 8 bytes, got %d", len(b))
+	}
+	var count uint64
+	for i := len(b) - 1; i >= 1; i-- {
+		count = count<<8 | uint64(b[i])
+	}
+	unit := typeMUnits[b[0]&0x03]
+	if count > uint64(1<<63-1)/uint64(unit) {
+		return 0, fmt.Errorf("type M lifetime overflows: %X", b)
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// DecodeBCDDate decodes three BCD bytes holding year (since 2000), month and
+// day in that order. Month and day may be zero.
+func DecodeBCDDate(b []byte) (Date, error) {
+	if len(b) < 3 {
+		return Date{}, fmt.Errorf("BCD date requires 3 bytes, got %d", len(b))
+	}
+	var fields [3]int
+	for i, by := range b[:3] {
+		if by&0x0F > 9 || by>>4 > 9 {
+			return Date{}, fmt.Errorf("invalid BCD digit in %02X", by)
+		}
+		fields[i] = int(by>>4)*10 + int(by&0x0F)
+	}
+	return Date{Year: 2000 + fields[0], Month: fields[1], Day: fields[2]}, nil
+}
+
+// Layouts used by the drivers to report decoded dates and times.
+const (
+	DateLayout     = "2006-01-02"
+	DateTimeLayout = "2006-01-02 15:04"
+	// DateTimeSecondsLayout is used for type I date/times, which carry seconds.
+	DateTimeSecondsLayout = "2006-01-02 15:04:05"
+	// TimeLayout formats a type J time of day.
+	TimeLayout = "15:04:05"
+)
+
+// RecordLayout returns the layout matching the resolution of a record
+// accepted by RecordDateTime: a date for type G, seconds for type I and
+// minutes otherwise.
+func RecordLayout(rec Record) string {
+	switch {
+	case rec.VIF == 0x6C:
+		return DateLayout
+	case len(rec.Data) == 6:
+		return DateTimeSecondsLayout
+	default:
+		return DateTimeLayout
+	}
+}
+
+// RecordDateTime decodes a date or date/time record: VIF 0x6C as type G and
+// VIF 0x6D as type F or I depending on the data length. The boolean is false
+// for records that carry no point in time, including the three-byte type J
+// time of day handled by RecordTimeOfDay. Types K and M have no plain VIF of
+// their own; callers decode them with DecodeTypeK and DecodeTypeM.
+func RecordDateTime(rec Record) (DateTime, bool, error) {
+	if len(rec.VIFE) > 0 {
+		return DateTime{}, false, nil
+	}
+	switch {
+	case rec.VIF == 0x6C && len(rec.Data) == 2:
+		dt, err := DecodeTypeG(rec.Data)
+		return dt, true, err
+	case rec.VIF == 0x6D && len(rec.Data) == 4:
+		dt, err := DecodeTypeF(rec.Data)
+		return dt, true, err
+	case rec.VIF == 0x6D && len(rec.Data) == 6:
+		dt, err := DecodeTypeI(rec.Data)
+		return dt, true, err
+	default:
+		return DateTime{}, false, nil
+	}
+}
+
+// RecordTimeOfDay decodes a VIF 0x6D record with three data bytes as a type J
+// time of day. The boolean is false for every other record.
+func RecordTimeOfDay(rec Record) (time.Duration, bool, error) {
+	if len(rec.VIFE) > 0 || rec.VIF != 0x6D || len(rec.Data) != 3 {
+		return 0, false, nil
+	}
+	tod, err := DecodeTypeJ(rec.Data)
+	return tod, true, err
+}
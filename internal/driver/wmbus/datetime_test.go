@@ -0,0 +1,135 @@
+package wmbus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeTypeF(t *testing.T) {
+	dt, err := DecodeTypeF([]byte{0x1E, 0x8A, 0xAF, 0x23})
+	if err != nil {
+		t.Fatalf("DecodeTypeF: %v", err)
+	}
+	want := time.Date(2021, time.March, 15, 10, 30, 0, 0, time.UTC)
+	if !dt.Time.Equal(want) || !dt.SummerTime || dt.Invalid {
+		t.Fatalf("got %+v, want %v with summer time", dt, want)
+	}
+	dt, err = DecodeTypeF([]byte{0x9E, 0x0A, 0xAF, 0x23})
+	if err != nil || !dt.Invalid || !dt.Time.IsZero() {
+		t.Fatalf("invalid flag: got %+v, %v", dt, err)
+	}
+	if _, err := DecodeTypeFDateTime([]byte{0x9E, 0x0A, 0xAF, 0x23}); err == nil {
+		t.Fatalf("DecodeTypeFDateTime accepted an invalid timestamp")
+	}
+}
+
+func TestDecodeTypeG(t *testing.T) {
+	dt, err := DecodeTypeG([]byte{0xAF, 0x23})
+	if err != nil {
+		t.Fatalf("DecodeTypeG: %v", err)
+	}
+	if want := time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC); !dt.Time.Equal(want) {
+		t.Fatalf("got %v, want %v", dt.Time, want)
+	}
+	for _, b := range [][]byte{{0x00, 0x00}, {0xFF, 0xFF}} {
+		dt, err := DecodeTypeG(b)
+		if err != nil || !dt.Invalid {
+			t.Fatalf("%X: got %+v, %v", b, dt, err)
+		}
+	}
+	if _, err := DecodeTypeG([]byte{0xA0, 0x23}); err == nil {
+		t.Fatalf("day 0 accepted")
+	}
+}
+
+func TestDecodeTypeI(t *testing.T) {
+	dt, err := DecodeTypeI([]byte{0x2D, 0x5E, 0x0A, 0xAF, 0x23, 0x00})
+	if err != nil {
+		t.Fatalf("DecodeTypeI: %v", err)
+	}
+	want := time.Date(2021, time.March, 15, 10, 30, 45, 0, time.UTC)
+	if !dt.Time.Equal(want) || !dt.SummerTime {
+		t.Fatalf("got %+v, want %v with summer time", dt, want)
+	}
+	dt, err = DecodeTypeI([]byte{0x2D, 0x9E, 0x0A, 0xAF, 0x23, 0x00})
+	if err != nil || !dt.Invalid {
+		t.Fatalf("invalid flag: got %+v, %v", dt, err)
+	}
+}
+
+func TestDecodeTypeJKM(t *testing.T) {
+	tod, err := DecodeTypeJ([]byte{0x2D, 0x1E, 0x0A})
+	if err != nil || tod != 10*time.Hour+30*time.Minute+45*time.Second {
+		t.Fatalf("DecodeTypeJ: got %v, %v", tod, err)
+	}
+	dst, err := DecodeTypeK([]byte{0x22, 0x1D, 0xA3, 0x19})
+	if err != nil {
+		t.Fatalf("DecodeTypeK: %v", err)
+	}
+	want := DST{BeginMonth: 3, BeginDay: 29, BeginHour: 2, EndMonth: 10, EndDay: 25, Deviation: time.Hour}
+	if dst != want {
+		t.Fatalf("DecodeTypeK: got %+v, want %+v", dst, want)
+	}
+	life, err := DecodeTypeM([]byte{0x02, 0x10, 0x00})
+	if err != nil || life != 16*time.Hour {
+		t.Fatalf("DecodeTypeM: got %v, %v", life, err)
+	}
+}
+
+func TestDecodeBCDDate(t *testing.T) {
+	cases := []struct {
+		in   []byte
+		want string
+		err  bool
+	}{
+		{[]byte{0x21, 0x03, 0x15}, "2021-03-15", false},
+		{[]byte{0x00, 0x00, 0x00}, "2000-00-00", false},
+		{[]byte{0x21, 0x1A, 0x15}, "", true},
+		{[]byte{0x21, 0x03}, "", true},
+	}
+	for _, tc := range cases {
+		got, err := DecodeBCDDate(tc.in)
+		if (err != nil) != tc.err {
+			t.Fatalf("%X: err = %v, want error %v", tc.in, err, tc.err)
+		}
+		if err == nil && got.String() != tc.want {
+			t.Fatalf("%X: got %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRecordDateTime(t *testing.T) {
+	dt, ok, err := RecordDateTime(Record{VIF: 0x6D, Data: []byte{0x2D, 0x1E, 0x0A, 0xAF, 0x23, 0x00}})
+	if err != nil || !ok || dt.Time.Second() != 45 {
+		t.Fatalf("type I record: got %+v, %v, %v", dt, ok, err)
+	}
+	if _, ok, _ := RecordDateTime(Record{VIF: 0x13, Data: []byte{1, 2, 3, 4}}); ok {
+		t.Fatalf("volume record decoded as date")
+	}
+}
+
+func TestRecordLayout(t *testing.T) {
+	cases := []struct {
+		rec  Record
+		want string
+	}{
+		{Record{VIF: 0x6C, Data: make([]byte, 2)}, DateLayout},
+		{Record{VIF: 0x6D, Data: make([]byte, 4)}, DateTimeLayout},
+		{Record{VIF: 0x6D, Data: make([]byte, 6)}, DateTimeSecondsLayout},
+	}
+	for _, tc := range cases {
+		if got := RecordLayout(tc.rec); got != tc.want {
+			t.Fatalf("VIF 0x%02X with %d bytes: got %q, want %q", tc.rec.VIF, len(tc.rec.Data), got, tc.want)
+		}
+	}
+}
+
+func TestRecordTimeOfDay(t *testing.T) {
+	tod, ok, err := RecordTimeOfDay(Record{VIF: 0x6D, Data: []byte{0x2D, 0x1E, 0x0A}})
+	if err != nil || !ok || tod != 10*time.Hour+30*time.Minute+45*time.Second {
+		t.Fatalf("got %v, %v, %v", tod, ok, err)
+	}
+	if _, ok, _ := RecordDateTime(Record{VIF: 0x6D, Data: []byte{0x2D, 0x1E, 0x0A}}); ok {
+		t.Fatalf("type J record decoded as a point in time")
+	}
+}
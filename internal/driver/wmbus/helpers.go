@@ -1,12 +1,10 @@
 package wmbus
 
-import (
-	"fmt"
-	"time"
-)
+import "fmt"
 
 // LengthForDIF returns the data length encoded in the lower nibble of the DIF
-// byte. The boolean indicates whether the DIF value is supported.
+// byte. The boolean is false for variable-length data (0x0D), whose size is
+// given by the LVAR byte preceding the data.
 func LengthForDIF(dif byte) (int, bool) {
 	switch dif & 0x0F {
 	case 0x00:
@@ -26,7 +24,7 @@ func LengthForDIF(dif byte) (int, bool) {
 	case 0x07:
 		return 8, true
 	case 0x08:
-		return 0, false // variable length not handled
+		return 0, true // selection for readout
 	case 0x09:
 		return 1, true
 	case 0x0A:
@@ -36,7 +34,7 @@ func LengthForDIF(dif byte) (int, bool) {
 	case 0x0C:
 		return 4, true
 	case 0x0D:
-		return 5, true
+		return 0, false // variable length (LVAR)
 	case 0x0E:
 		return 6, true
 	case 0x0F:
@@ -64,22 +62,3 @@ func DecodeBCDLittleEndian(b []byte) (int, error) {
 	}
 	return value, nil
 }
-
-// DecodeTypeFDateTime decodes the four-byte Type F timestamp used by many
-// Wireless M-Bus meters.
-func DecodeTypeFDateTime(b []byte) (time.Time, error) {
-	if len(b) != 4 {
-		return time.Time{}, fmt.Errorf("type F datetime requires 4 bytes, got %d", len(b))
-	}
-	minute := int(b[0] & 0x3F)
-	hour := int(b[1] & 0x1F)
-	day := int(b[2] & 0x1F)
-	month := int(b[3] & 0x0F)
-	yearBitsHigh := (b[3] >> 4) & 0x0F
-	yearBitsLow := (b[2] >> 5) & 0x07
-	year := 2000 + int(yearBitsHigh<<3|yearBitsLow)
-	if minute > 59 || hour > 23 || day == 0 || day > 31 || month == 0 || month > 12 {
-		return time.Time{}, fmt.Errorf("invalid type F datetime encoding: %02X%02X%02X%02X", b[0], b[1], b[2], b[3])
-	}
-	return time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.UTC), nil
-}
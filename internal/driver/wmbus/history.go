@@ -0,0 +1,48 @@
+package wmbus
+
+import "sort"
+
+// StorageSlot groups the records of one historic storage number (1 and up),
+// e.g. a billing date, with the date record that time-stamps them.
+type StorageSlot struct {
+	Storage int
+	// Date is decoded from the slot's own date or date/time record. HasDate
+	// is false when the meter sent none for this storage number.
+	Date    DateTime
+	HasDate bool
+	// Records holds the slot's values without the date record.
+	Records []Record
+}
+
+// SplitStorage separates the current values (storage number 0) from the
+// historic storage slots. Slots are ordered by storage number and each takes
+// the first date record of its own storage number as its date.
+func SplitStorage(recs []Record) ([]Record, []StorageSlot) {
+	var current []Record
+	slots := make(map[int]*StorageSlot)
+	for _, rec := range recs {
+		if rec.Storage == 0 || rec.IsManufacturerData() {
+			current = append(current, rec)
+			continue
+		}
+		slot, ok := slots[rec.Storage]
+		if !ok {
+			slot = &StorageSlot{Storage: rec.Storage}
+			slots[rec.Storage] = slot
+		}
+		if !slot.HasDate {
+			if dt, isDate, err := RecordDateTime(rec); isDate && err == nil {
+				slot.Date = dt
+				slot.HasDate = true
+				continue
+			}
+		}
+		slot.Records = append(slot.Records, rec)
+	}
+	history := make([]StorageSlot, 0, len(slots))
+	for _, slot := range slots {
+		history = append(history, *slot)
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Storage < history[j].Storage })
+	return current, history
+}
@@ -0,0 +1,25 @@
+package wmbus
+
+import "testing"
+
+func TestSplitStorage(t *testing.T) {
+	recs := []Record{
+		{VIF: 0x13, Data: []byte{1, 0, 0, 0}},
+		{VIF: 0x13, Storage: 2, Data: []byte{3, 0, 0, 0}},
+		{VIF: 0x6C, Storage: 1, Data: []byte{0x1F, 0x3C}},
+		{VIF: 0x13, Storage: 1, Data: []byte{2, 0, 0, 0}},
+	}
+	current, history := SplitStorage(recs)
+	if len(current) != 1 || current[0].Data[0] != 1 {
+		t.Fatalf("current = %+v", current)
+	}
+	if len(history) != 2 || history[0].Storage != 1 || history[1].Storage != 2 {
+		t.Fatalf("history = %+v", history)
+	}
+	if !history[0].HasDate || history[0].Date.Time.Day() != 31 || len(history[0].Records) != 1 {
+		t.Fatalf("slot 1 = %+v", history[0])
+	}
+	if history[1].HasDate {
+		t.Fatalf("slot 2 has no date record: %+v", history[1])
+	}
+}
@@ -0,0 +1,175 @@
+package wmbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/d21d3q/gowmbus/internal/records"
+)
+
+// ValueKind identifies how a record data field was coded.
+type ValueKind int
+
+const (
+	// ValueNone marks data field codings without a value (0x00, 0x08).
+	ValueNone ValueKind = iota
+	// ValueInteger covers the binary codings 0x01-0x04, 0x06 and 0x07.
+	ValueInteger
+	// ValueReal covers the 32-bit IEEE 754 coding 0x05.
+	ValueReal
+	// ValueBCD covers the BCD codings 0x09-0x0C and 0x0E.
+	ValueBCD
+	// ValueString is variable-length ASCII text (LVAR 0x00-0xBF).
+	ValueString
+	// ValueBinary is a variable-length binary number or bit string too long
+	// to fit an int64.
+	ValueBinary
+)
+
+// Value is a record data field decoded according to its DIF.
+type Value struct {
+	Kind  ValueKind
+	Int   int64
+	Real  float64
+	Text  string
+	Bytes []byte
+	// Invalid is set when the meter sent an "invalid/no data" marker: the
+	// most negative integer, a NaN real, or non-decimal BCD digits.
+	Invalid bool
+}
+
+// Valid reports whether the value carries usable data.
+func (v Value) Valid() bool {
+	return v.Kind != ValueNone && !v.Invalid
+}
+
+// Numeric reports whether Float returns a meaningful number.
+func (v Value) Numeric() bool {
+	switch v.Kind {
+	case ValueInteger, ValueReal, ValueBCD:
+		return true
+	default:
+		return false
+	}
+}
+
+// Float returns the value as float64 regardless of its coding.
+func (v Value) Float() float64 {
+	if v.Kind == ValueReal {
+		return v.Real
+	}
+	return float64(v.Int)
+}
+
+// RecordValue decodes the data field of a record, including variable-length
+// data announced by an LVAR byte.
+func RecordValue(rec Record) (Value, error) {
+	if rec.DataField() == 0x0D {
+		return DecodeVariable(rec.LVAR, rec.Data)
+	}
+	return DecodeValue(rec.DIF, rec.Data)
+}
+
+// DecodeVariable decodes variable-length data as selected by the LVAR byte:
+// ASCII text (transmitted last character first), positive or negative BCD,
+// or a binary number.
+func DecodeVariable(lvar byte, data []byte) (Value, error) {
+	length, err := records.LVARLength(lvar)
+	if err != nil {
+		return Value{}, err
+	}
+	if len(data) != length {
+		return Value{}, fmt.Errorf("LVAR 0x%02X expects %d bytes, got %d", lvar, length, len(data))
+	}
+	switch {
+	case lvar <= 0xBF:
+		text := make([]byte, len(data))
+		for i, b := range data {
+			text[len(data)-1-i] = b
+		}
+		return Value{Kind: ValueString, Text: string(text)}, nil
+	case len(data) == 0:
+		return Value{Kind: ValueNone}, nil
+	case lvar <= 0xC9:
+		return decodeSignedBCD(data), nil
+	case lvar <= 0xD9:
+		// The LVAR already makes the value negative; a 0xF sign nibble
+		// must not turn it positive again.
+		v := decodeSignedBCD(data)
+		if v.Int > 0 {
+			v.Int = -v.Int
+		}
+		return v, nil
+	case len(data) <= 8:
+		return decodeInteger(data), nil
+	default:
+		return Value{Kind: ValueBinary, Bytes: data}, nil
+	}
+}
+
+// DecodeValue decodes data according to the data field coding in the lower
+// nibble of dif. Integers are little-endian two's complement, BCD values may
+// carry a negative sign in their most significant nibble (0xF).
+func DecodeValue(dif byte, data []byte) (Value, error) {
+	coding := dif & 0x0F
+	switch coding {
+	case 0x00, 0x08:
+		return Value{Kind: ValueNone}, nil
+	case 0x0D:
+		return Value{}, fmt.Errorf("DIF 0x%02X is variable length, use DecodeVariable", dif)
+	case 0x0F:
+		return Value{}, fmt.Errorf("DIF 0x%02X has no fixed-size value", dif)
+	}
+	if length, _ := LengthForDIF(coding); len(data) != length {
+		return Value{}, fmt.Errorf("DIF 0x%02X expects %d bytes, got %d", dif, length, len(data))
+	}
+	switch coding {
+	case 0x05:
+		f := math.Float32frombits(binary.LittleEndian.Uint32(data))
+		return Value{Kind: ValueReal, Real: float64(f), Invalid: math.IsNaN(float64(f))}, nil
+	case 0x01, 0x02, 0x03, 0x04, 0x06, 0x07:
+		return decodeInteger(data), nil
+	default:
+		return decodeSignedBCD(data), nil
+	}
+}
+
+// decodeInteger sign-extends a little-endian integer of 1 to 8 bytes.
+func decodeInteger(data []byte) Value {
+	var u uint64
+	for i := len(data) - 1; i >= 0; i-- {
+		u = u<<8 | uint64(data[i])
+	}
+	bits := uint(len(data) * 8)
+	shift := 64 - bits
+	n := int64(u<<shift) >> shift
+	minimum := int64(-1) << (bits - 1)
+	return Value{Kind: ValueInteger, Int: n, Invalid: n == minimum}
+}
+
+// decodeSignedBCD decodes a little-endian BCD value whose most significant
+// nibble may be 0xF to indicate a negative number.
+func decodeSignedBCD(data []byte) Value {
+	v := Value{Kind: ValueBCD}
+	negative := false
+	multiplier := int64(1)
+	for i, by := range data {
+		for j, digit := range []byte{by & 0x0F, by >> 4} {
+			msd := i == len(data)-1 && j == 1
+			switch {
+			case digit <= 9:
+				v.Int += int64(digit) * multiplier
+			case digit == 0x0F && msd:
+				negative = true
+			default:
+				v.Invalid = true
+			}
+			multiplier *= 10
+		}
+	}
+	if negative {
+		v.Int = -v.Int
+	}
+	return v
+}
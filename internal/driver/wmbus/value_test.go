@@ -0,0 +1,100 @@
+package wmbus
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeValue(t *testing.T) {
+	cases := []struct {
+		name    string
+		dif     byte
+		data    []byte
+		kind    ValueKind
+		want    float64
+		invalid bool
+	}{
+		{"int8 negative", 0x01, []byte{0xFE}, ValueInteger, -2, false},
+		{"int16", 0x02, []byte{0x34, 0x12}, ValueInteger, 0x1234, false},
+		{"int24 negative", 0x03, []byte{0xFF, 0xFF, 0xFF}, ValueInteger, -1, false},
+		{"int32", 0x04, []byte{0x10, 0x27, 0x00, 0x00}, ValueInteger, 10000, false},
+		{"int48", 0x06, []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x01}, ValueInteger, 1<<40 + 1, false},
+		{"int64", 0x07, []byte{0x02, 0, 0, 0, 0, 0, 0, 0}, ValueInteger, 2, false},
+		{"int16 invalid", 0x02, []byte{0x00, 0x80}, ValueInteger, math.MinInt16, true},
+		{"real", 0x05, []byte{0x00, 0x00, 0xC0, 0x3F}, ValueReal, 1.5, false},
+		{"bcd8", 0x0C, []byte{0x66, 0x38, 0x00, 0x00}, ValueBCD, 3866, false},
+		{"bcd negative", 0x0A, []byte{0x23, 0xF1}, ValueBCD, -123, false},
+		{"bcd invalid", 0x0B, []byte{0xFF, 0xFF, 0xFF}, ValueBCD, 0, true},
+		{"no data", 0x08, nil, ValueNone, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := DecodeValue(tc.dif, tc.data)
+			if err != nil {
+				t.Fatalf("DecodeValue: %v", err)
+			}
+			if v.Kind != tc.kind || v.Invalid != tc.invalid {
+				t.Fatalf("unexpected value %+v", v)
+			}
+			if !tc.invalid && math.Abs(v.Float()-tc.want) > 1e-9 {
+				t.Fatalf("got %v want %v", v.Float(), tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeValueLengthMismatch(t *testing.T) {
+	if _, err := DecodeValue(0x04, []byte{0x01}); err == nil {
+		t.Fatalf("expected length error")
+	}
+}
+
+func TestDecodeVariable(t *testing.T) {
+	recs, err := ParseRecords([]byte{
+		0x0D, 0x78, 0x04, 0x34, 0x2E, 0x31, 0x56, // fabrication number "V1.4"
+		0x0D, 0xFD, 0x0E, 0xD2, 0x45, 0x23, // negative BCD -2345
+		0x0D, 0xFD, 0x11, 0xE3, 0x01, 0x02, 0x00, // binary 0x000201
+		0x04, 0x13, 0x10, 0x00, 0x00, 0x00,
+	})
+	if err != nil {
+		t.Fatalf("ParseRecords: %v", err)
+	}
+	if len(recs) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(recs))
+	}
+	text, err := RecordValue(recs[0])
+	if err != nil || text.Kind != ValueString || text.Text != "V1.4" {
+		t.Fatalf("unexpected text value %+v (%v)", text, err)
+	}
+	bcd, err := RecordValue(recs[1])
+	if err != nil || bcd.Kind != ValueBCD || bcd.Int != -2345 {
+		t.Fatalf("unexpected BCD value %+v (%v)", bcd, err)
+	}
+	bin, err := RecordValue(recs[2])
+	if err != nil || bin.Kind != ValueInteger || bin.Int != 0x0201 {
+		t.Fatalf("unexpected binary value %+v (%v)", bin, err)
+	}
+	if recs[3].VIF != 0x13 {
+		t.Fatalf("record after LVAR data lost: %+v", recs[3])
+	}
+}
+
+func TestDecodeVariableEdgeCases(t *testing.T) {
+	for _, lvar := range []byte{0xC0, 0xD0, 0xE0} {
+		v, err := DecodeVariable(lvar, nil)
+		if err != nil || v.Kind != ValueNone {
+			t.Fatalf("LVAR 0x%02X without data: got %+v (%v)", lvar, v, err)
+		}
+	}
+	// Negative BCD -12 with a 0xF sign nibble as well.
+	v, err := DecodeVariable(0xD2, []byte{0x12, 0xF0})
+	if err != nil || v.Int != -12 {
+		t.Fatalf("negative BCD with sign nibble: got %+v (%v)", v, err)
+	}
+}
+
+func TestDecodeVariableReserved(t *testing.T) {
+	if _, err := DecodeVariable(0xF8, nil); err == nil {
+		t.Fatalf("expected error for reserved LVAR")
+	}
+}
@@ -0,0 +1,141 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const ciAFL = 0x90
+
+// ErrFragmented reports an AFL message split across several telegrams. Only
+// single-fragment messages are decoded until reassembly is implemented.
+var ErrFragmented = errors.New("fragmented AFL message: reassembly not supported")
+
+// AFLInfo describes the Authentication and Fragmentation Layer (CI 0x90) that
+// precedes the transport layer.
+type AFLInfo struct {
+	Present         bool
+	Length          byte
+	FragmentControl uint16
+	MessageControl  byte
+	KeyInfo         uint16
+	MessageCounter  uint32
+	MAC             []byte
+	MessageLength   uint16
+}
+
+// FragmentID returns the FID field of the fragmentation control field.
+func (a AFLInfo) FragmentID() byte { return byte(a.FragmentControl) }
+
+// MoreFragments reports the MF bit: further fragments follow this one.
+func (a AFLInfo) MoreFragments() bool { return a.FragmentControl&0x4000 != 0 }
+
+// HasMessageControl reports the MCLP bit of the fragmentation control field.
+func (a AFLInfo) HasMessageControl() bool { return a.FragmentControl&0x2000 != 0 }
+
+// HasMessageLength reports the MLP bit of the fragmentation control field.
+func (a AFLInfo) HasMessageLength() bool { return a.FragmentControl&0x1000 != 0 }
+
+// HasMessageCounter reports the MCRP bit of the fragmentation control field.
+func (a AFLInfo) HasMessageCounter() bool { return a.FragmentControl&0x0800 != 0 }
+
+// HasMAC reports the MACP bit of the fragmentation control field.
+func (a AFLInfo) HasMAC() bool { return a.FragmentControl&0x0400 != 0 }
+
+// HasKeyInfo reports the KIP bit of the fragmentation control field.
+func (a AFLInfo) HasKeyInfo() bool { return a.FragmentControl&0x0200 != 0 }
+
+// AuthenticationType returns the AT field of the message control byte.
+func (a AFLInfo) AuthenticationType() byte { return a.MessageControl & 0x0F }
+
+// LengthInMAC reports the MLMP bit: the message length is part of the MAC.
+func (a AFLInfo) LengthInMAC() bool { return a.MessageControl&0x40 != 0 }
+
+// CounterInMAC reports the MCMP bit: the message counter is part of the MAC.
+func (a AFLInfo) CounterInMAC() bool { return a.MessageControl&0x20 != 0 }
+
+// KeyInfoInMAC reports the KIMP bit: the key information is part of the MAC.
+func (a AFLInfo) KeyInfoInMAC() bool { return a.MessageControl&0x10 != 0 }
+
+// macLength maps the authentication type to the transmitted MAC size.
+func macLength(at byte) (int, bool) {
+	switch at {
+	case 0x00:
+		return 0, true
+	case 0x03:
+		return 2, true
+	case 0x04:
+		return 4, true
+	case 0x05:
+		return 8, true
+	case 0x06:
+		return 12, true
+	case 0x07:
+		return 16, true
+	case 0x08:
+		return 12, true
+	default:
+		return 0, false
+	}
+}
+
+// parseAFL decodes the AFL starting at the CI byte found at offset and
+// returns the number of bytes consumed including the CI.
+func parseAFL(data []byte, offset int) (AFLInfo, int, error) {
+	if len(data) < offset+4 {
+		return AFLInfo{}, 0, fmt.Errorf("AFL header truncated")
+	}
+	afl := AFLInfo{
+		Present:         true,
+		Length:          data[offset+1],
+		FragmentControl: binary.LittleEndian.Uint16(data[offset+2 : offset+4]),
+	}
+	end := offset + 2 + int(afl.Length)
+	if end > len(data) || afl.Length < 2 {
+		return AFLInfo{}, 0, fmt.Errorf("AFL length %d exceeds telegram", afl.Length)
+	}
+	cursor := offset + 4
+	if afl.HasMessageControl() {
+		if cursor+1 > end {
+			return AFLInfo{}, 0, fmt.Errorf("AFL message control truncated")
+		}
+		afl.MessageControl = data[cursor]
+		cursor++
+	}
+	if afl.HasKeyInfo() {
+		if cursor+2 > end {
+			return AFLInfo{}, 0, fmt.Errorf("AFL key information truncated")
+		}
+		afl.KeyInfo = binary.LittleEndian.Uint16(data[cursor : cursor+2])
+		cursor += 2
+	}
+	if afl.HasMessageCounter() {
+		if cursor+4 > end {
+			return AFLInfo{}, 0, fmt.Errorf("AFL message counter truncated")
+		}
+		afl.MessageCounter = binary.LittleEndian.Uint32(data[cursor : cursor+4])
+		cursor += 4
+	}
+	if afl.HasMAC() {
+		size, ok := macLength(afl.AuthenticationType())
+		if !ok {
+			return AFLInfo{}, 0, fmt.Errorf("unsupported AFL authentication type %d", afl.AuthenticationType())
+		}
+		if cursor+size > end {
+			return AFLInfo{}, 0, fmt.Errorf("AFL MAC truncated")
+		}
+		afl.MAC = data[cursor : cursor+size]
+		cursor += size
+	}
+	if afl.HasMessageLength() {
+		if cursor+2 > end {
+			return AFLInfo{}, 0, fmt.Errorf("AFL message length truncated")
+		}
+		afl.MessageLength = binary.LittleEndian.Uint16(data[cursor : cursor+2])
+	}
+	if afl.MoreFragments() || (afl.HasMessageLength() && int(afl.MessageLength) > len(data)-end) {
+		return AFLInfo{}, 0, fmt.Errorf("%w (fragment %d)", ErrFragmented, afl.FragmentID())
+	}
+	return afl, end - offset, nil
+}
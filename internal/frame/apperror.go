@@ -0,0 +1,107 @@
+package frame
+
+import "fmt"
+
+const (
+	ciAppErrorShort = 0x6E
+	ciAppErrorLong  = 0x6F
+	ciAppErrorNone  = 0x70
+	ciAlarmNone     = 0x71
+	ciAlarmShort    = 0x74
+	ciAlarmLong     = 0x75
+)
+
+// appErrorDescriptions lists the application error codes of EN 13757-3.
+var appErrorDescriptions = []string{
+	"unspecified error",
+	"unimplemented CI field",
+	"buffer too long, truncated",
+	"too many records",
+	"premature end of record",
+	"more than 10 DIFE",
+	"more than 10 VIFE",
+	"reserved",
+	"application too busy for handling readout request",
+	"too many readouts",
+}
+
+// ApplicationError is the content of an application error telegram
+// (CI 0x6E, 0x6F or 0x70).
+type ApplicationError struct {
+	Code byte
+	// Data holds any bytes following the error code.
+	Data []byte
+}
+
+// Description returns the EN 13757-3 meaning of the error code.
+func (e ApplicationError) Description() string {
+	if int(e.Code) < len(appErrorDescriptions) {
+		return appErrorDescriptions[e.Code]
+	}
+	return fmt.Sprintf("reserved (0x%02X)", e.Code)
+}
+
+// Alarm is the content of an alarm telegram (CI 0x71, 0x74 or 0x75).
+type Alarm struct {
+	// Status holds the alarm status bytes; their bit assignment is
+	// manufacturer specific.
+	Status []byte
+}
+
+// Active reports whether any alarm bit is set.
+func (a Alarm) Active() bool {
+	for _, b := range a.Status {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Bits lists the set alarm bits, numbered from bit 0 of the first byte.
+func (a Alarm) Bits() []int {
+	var bits []int
+	for i, b := range a.Status {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				bits = append(bits, i*8+bit)
+			}
+		}
+	}
+	return bits
+}
+
+// IsApplicationError reports whether the transport CI announces an
+// application error telegram.
+func (t Telegram) IsApplicationError() bool {
+	ci := t.AppCI
+	return ci == ciAppErrorShort || ci == ciAppErrorLong || ci == ciAppErrorNone
+}
+
+// IsAlarm reports whether the transport CI announces an alarm telegram.
+func (t Telegram) IsAlarm() bool {
+	ci := t.AppCI
+	return ci == ciAlarmNone || ci == ciAlarmShort || ci == ciAlarmLong
+}
+
+// ApplicationError decodes the payload of an application error telegram. An
+// empty payload means an unspecified error. The payload must be in
+// plaintext.
+func (t Telegram) ApplicationError() (ApplicationError, bool) {
+	if !t.IsApplicationError() {
+		return ApplicationError{}, false
+	}
+	if len(t.Payload) == 0 {
+		return ApplicationError{}, true
+	}
+	return ApplicationError{Code: t.Payload[0], Data: t.Payload[1:]}, true
+}
+
+// Alarm decodes the payload of an alarm telegram. The payload must be in
+// plaintext.
+func (t Telegram) Alarm() (Alarm, bool) {
+	if !t.IsAlarm() {
+		return Alarm{}, false
+	}
+	return Alarm{Status: t.Payload}, true
+}
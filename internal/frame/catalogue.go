@@ -0,0 +1,109 @@
+package frame
+
+import "fmt"
+
+// deviceTypeNames lists the device types (media) of EN 13757-7 table 12 and
+// the OMS additions, spelled the way drivers report the "media" field.
+var deviceTypeNames = map[byte]string{
+	0x00: "other",
+	0x01: "oil",
+	0x02: "electricity",
+	0x03: "gas",
+	0x04: "heat",
+	0x05: "steam",
+	0x06: "warm water",
+	0x07: "water",
+	0x08: "heat cost allocation",
+	0x09: "compressed air",
+	0x0A: "cooling load volume at outlet",
+	0x0B: "cooling load volume at inlet",
+	0x0C: "heat volume at inlet",
+	0x0D: "heat/cooling load",
+	0x0E: "bus/system component",
+	0x0F: "unknown",
+	0x10: "irrigation water",
+	0x11: "water data logger",
+	0x12: "gas data logger",
+	0x13: "gas converter",
+	0x14: "calorific value",
+	0x15: "hot water",
+	0x16: "cold water",
+	0x17: "dual register water",
+	0x18: "pressure",
+	0x19: "A/D converter",
+	0x1A: "smoke detector",
+	0x1B: "room sensor",
+	0x1C: "gas detector",
+	0x20: "electricity breaker",
+	0x21: "valve",
+	0x25: "customer unit",
+	0x28: "waste water",
+	0x29: "garbage",
+	0x31: "communication controller",
+	0x32: "unidirectional repeater",
+	0x33: "bidirectional repeater",
+	0x36: "radio converter (system side)",
+	0x37: "radio converter (meter side)",
+}
+
+// ciNames lists the CI fields of EN 13757-7 table 2 and EN 13757-4.
+var ciNames = map[byte]string{
+	0x50:         "application reset or select",
+	0x51:         "command (no TPL)",
+	0x52:         "selection of device",
+	0x53:         "application reset or select (long TPL)",
+	0x5A:         "command (short TPL)",
+	0x5B:         "command (long TPL)",
+	0x60:         "COSEM command (long TPL)",
+	0x61:         "COSEM command (short TPL)",
+	0x6C:         "time sync absolute",
+	0x6D:         "time sync relative",
+	0x6E:         "application error (short TPL)",
+	0x6F:         "application error (long TPL)",
+	0x70:         "application error (no TPL)",
+	0x71:         "alarm (no TPL)",
+	0x72:         "RSP_UD (long TPL)",
+	0x74:         "alarm (short TPL)",
+	0x75:         "alarm (long TPL)",
+	0x78:         "RSP_UD (no TPL)",
+	0x7A:         "RSP_UD (short TPL)",
+	0x7C:         "COSEM response (long TPL)",
+	0x7D:         "COSEM response (short TPL)",
+	0x80:         "TPL only (long, to meter)",
+	0x8A:         "TPL only (short, from meter)",
+	0x8B:         "TPL only (long, from meter)",
+	ciELLShort:   "ELL I",
+	ciELLSession: "ELL II",
+	0x8E:         "ELL III",
+	0x8F:         "ELL IV",
+	ciAFL:        "AFL",
+}
+
+// DeviceTypeName returns the medium announced by a device type byte.
+// Reserved values are reported as "reserved (0xNN)".
+func DeviceTypeName(deviceType byte) string {
+	if name, ok := deviceTypeNames[deviceType]; ok {
+		return name
+	}
+	return fmt.Sprintf("reserved (0x%02X)", deviceType)
+}
+
+// CIName describes a CI field. Manufacturer-specific and reserved values are
+// reported with their hex code.
+func CIName(ci byte) string {
+	if name, ok := ciNames[ci]; ok {
+		return name
+	}
+	if ci >= 0xA0 && ci <= 0xB7 {
+		return fmt.Sprintf("manufacturer specific (0x%02X)", ci)
+	}
+	return fmt.Sprintf("reserved (0x%02X)", ci)
+}
+
+// DeviceTypeName returns the medium of the address.
+func (a Address) DeviceTypeName() string { return DeviceTypeName(a.DeviceType) }
+
+// Media returns the medium of the meter identity, e.g. "water".
+func (t Telegram) Media() string {
+	return t.Identity().DeviceTypeName()
+}
@@ -0,0 +1,122 @@
+package frame
+
+// FrameKind is the link-layer function announced by the C field.
+type FrameKind int
+
+const (
+	FrameUnknown FrameKind = iota
+	// FrameSndNke resets the link of a meter (SND_NKE).
+	FrameSndNke
+	// FrameSndUD sends user data, e.g. a command, to a meter (SND_UD).
+	FrameSndUD
+	// FrameSndNR is a spontaneous reading that expects no reply (SND_NR).
+	FrameSndNR
+	// FrameSndIR is an installation request carrying meter data (SND_IR).
+	FrameSndIR
+	// FrameAccNR offers an access window without data (ACC_NR).
+	FrameAccNR
+	// FrameAccDmd asks the collector for access (ACC_DMD).
+	FrameAccDmd
+	// FrameReqUD1 requests class 1 (alarm) data (REQ_UD1).
+	FrameReqUD1
+	// FrameReqUD2 requests class 2 (readout) data (REQ_UD2).
+	FrameReqUD2
+	// FrameAck acknowledges a frame (ACK).
+	FrameAck
+	// FrameNack rejects a frame (NACK).
+	FrameNack
+	// FrameCnfIR confirms an installation request (CNF_IR).
+	FrameCnfIR
+	// FrameRspUD answers a request with user data (RSP_UD).
+	FrameRspUD
+)
+
+var frameKindNames = map[FrameKind]string{
+	FrameUnknown: "unknown",
+	FrameSndNke:  "SND_NKE",
+	FrameSndUD:   "SND_UD",
+	FrameSndNR:   "SND_NR",
+	FrameSndIR:   "SND_IR",
+	FrameAccNR:   "ACC_NR",
+	FrameAccDmd:  "ACC_DMD",
+	FrameReqUD1:  "REQ_UD1",
+	FrameReqUD2:  "REQ_UD2",
+	FrameAck:     "ACK",
+	FrameNack:    "NACK",
+	FrameCnfIR:   "CNF_IR",
+	FrameRspUD:   "RSP_UD",
+}
+
+// String returns the EN 13757-4 mnemonic of the frame kind.
+func (k FrameKind) String() string {
+	if name, ok := frameKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// CarriesData reports whether frames of this kind transport meter data for
+// drivers. Unknown C fields are passed on to keep unusual meters readable.
+func (k FrameKind) CarriesData() bool {
+	switch k {
+	case FrameSndNR, FrameSndIR, FrameRspUD, FrameUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	controlPRM      = 0x40
+	controlFunction = 0x0F
+)
+
+// DecodeControl maps a C field to its frame kind. The FCB/FCV bits of the
+// primary station and the ACD/DFC bits of the secondary station are ignored.
+func DecodeControl(c byte) FrameKind {
+	function := c & controlFunction
+	if c&controlPRM != 0 {
+		switch function {
+		case 0x0:
+			return FrameSndNke
+		case 0x3:
+			return FrameSndUD
+		case 0x4:
+			return FrameSndNR
+		case 0x6:
+			return FrameSndIR
+		case 0x7:
+			return FrameAccNR
+		case 0x8:
+			return FrameAccDmd
+		case 0xA:
+			return FrameReqUD1
+		case 0xB:
+			return FrameReqUD2
+		}
+		return FrameUnknown
+	}
+	switch function {
+	case 0x0:
+		return FrameAck
+	case 0x1:
+		return FrameNack
+	case 0x6:
+		return FrameCnfIR
+	case 0x8:
+		return FrameRspUD
+	}
+	return FrameUnknown
+}
+
+// Kind decodes the C field of the telegram.
+func (t Telegram) Kind() FrameKind {
+	return DecodeControl(t.Control)
+}
+
+// HasApplicationData reports whether the telegram should be handed to a
+// driver: its C field announces data and an application payload follows the
+// headers.
+func (t Telegram) HasApplicationData() bool {
+	return t.Kind().CarriesData() && len(t.Payload) > 0
+}
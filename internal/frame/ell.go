@@ -0,0 +1,106 @@
+package frame
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	ciELLShort   = 0x8C
+	ciELLSession = 0x8D
+)
+
+// ELLInfo describes the Extended Link Layer announced by CI 0x8C (CC, ACC) or
+// CI 0x8D (CC, ACC, session number and payload CRC).
+type ELLInfo struct {
+	Present       bool
+	CI            byte
+	Communication byte
+	AccessNumber  byte
+	HasSession    bool
+	SessionNumber uint32
+	// PayloadCRC is only populated once the payload is in plaintext; for
+	// encrypted sessions it is recovered by the crypto package.
+	PayloadCRC uint16
+}
+
+// Bidirectional reports the B bit of the communication control field.
+func (e ELLInfo) Bidirectional() bool { return e.Communication&0x80 != 0 }
+
+// ResponseDelay reports the D bit of the communication control field.
+func (e ELLInfo) ResponseDelay() bool { return e.Communication&0x40 != 0 }
+
+// Synchronous reports the S bit of the communication control field.
+func (e ELLInfo) Synchronous() bool { return e.Communication&0x20 != 0 }
+
+// Hopped reports whether a repeater relayed the telegram (H bit).
+func (e ELLInfo) Hopped() bool { return e.Communication&0x10 != 0 }
+
+// Priority reports the P bit of the communication control field.
+func (e ELLInfo) Priority() bool { return e.Communication&0x08 != 0 }
+
+// Accessible reports the A bit of the communication control field.
+func (e ELLInfo) Accessible() bool { return e.Communication&0x04 != 0 }
+
+// Repeated reports the R bit of the communication control field.
+func (e ELLInfo) Repeated() bool { return e.Communication&0x02 != 0 }
+
+// EncryptionMode returns the ENC field (bits 29-31) of the session number.
+// Zero means the payload is not encrypted, one selects AES-128-CTR.
+func (e ELLInfo) EncryptionMode() byte {
+	return byte(e.SessionNumber >> 29)
+}
+
+// SessionTime returns the minute counter (bits 4-28) of the session number.
+func (e ELLInfo) SessionTime() uint32 {
+	return (e.SessionNumber >> 4) & 0x01FFFFFF
+}
+
+// Session returns the session counter (bits 0-3) of the session number.
+func (e ELLInfo) Session() byte {
+	return byte(e.SessionNumber & 0x0F)
+}
+
+// Encrypted reports whether the payload following the session number is
+// still encrypted.
+func (e ELLInfo) Encrypted() bool {
+	return e.HasSession && e.EncryptionMode() != 0
+}
+
+func isELL(ci byte) bool {
+	return ci == ciELLShort || ci == ciELLSession
+}
+
+// parseELL decodes the ELL starting at the CI byte found at offset. For an
+// encrypted session the payload CRC is left in place.
+func parseELL(data []byte, offset int) (ELLInfo, int, error) {
+	ci := data[offset]
+	if len(data) < offset+3 {
+		return ELLInfo{}, 0, fmt.Errorf("ELL header truncated")
+	}
+	ell := ELLInfo{
+		Present:       true,
+		CI:            ci,
+		Communication: data[offset+1],
+		AccessNumber:  data[offset+2],
+	}
+	consumed := 3
+	if ci != ciELLSession {
+		return ell, consumed, nil
+	}
+	if len(data) < offset+consumed+4 {
+		return ELLInfo{}, 0, fmt.Errorf("ELL session number truncated")
+	}
+	ell.HasSession = true
+	ell.SessionNumber = binary.LittleEndian.Uint32(data[offset+consumed : offset+consumed+4])
+	consumed += 4
+	if ell.Encrypted() {
+		return ell, consumed, nil
+	}
+	if len(data) < offset+consumed+2 {
+		return ELLInfo{}, 0, fmt.Errorf("ELL payload CRC truncated")
+	}
+	ell.PayloadCRC = binary.LittleEndian.Uint16(data[offset+consumed : offset+consumed+2])
+	consumed += 2
+	return ell, consumed, nil
+}
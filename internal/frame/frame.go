@@ -8,33 +8,48 @@ import (
 // Telegram represents a decoded Wireless M-Bus frame stripped from transport
 // details. The structure will expand as parsing capabilities are added.
 type Telegram struct {
-	Raw          []byte
-	Length       byte
-	Control      byte
-	Manufacturer uint16
-	MeterID      [4]byte
-	Version      byte
-	DeviceType   byte
+	Raw    []byte
+	Length byte
+	// Wired marks telegrams received as wired M-Bus long frames; those carry
+	// a primary address instead of the wireless link-layer address.
+	Wired          bool
+	PrimaryAddress byte
+	Control        byte
+	Manufacturer   uint16
+	MeterID        [4]byte
+	Version        byte
+	DeviceType     byte
+	// CI is the first CI field following the link layer. When an Extended
+	// Link Layer precedes the transport layer, AppCI holds the chained CI.
 	CI           byte
+	AppCI        byte
 	AccessNumber byte
-	Status       byte
+	Status       Status
+	ELL          ELLInfo
+	AFL          AFLInfo
 	TPL          TPLInfo
-	StatusFlags  map[string]bool
-	Payload      []byte
+	// AppAddress holds the meter identity carried by a long transport header
+	// (CI 0x72). It is nil when the telegram uses a short or no header.
+	AppAddress  *Address
+	StatusFlags map[string]bool
+	Payload     []byte
 }
 
-type TPLInfo struct {
-	Present         bool
-	AccessField     byte
-	StatusField     byte
-	Config          uint16
-	SecurityMode    byte
-	EncryptedBlocks int
+// Address identifies a meter by manufacturer, serial number, version and
+// device type.
+type Address struct {
+	Manufacturer uint16
+	ID           [4]byte
+	Version      byte
+	DeviceType   byte
 }
 
+// linkHeaderLen covers L, C, M, A (ID, version, device type).
+const linkHeaderLen = 10
+
 // Parse extracts the standard short (T1) header from a raw frame.
 func Parse(raw []byte) (Telegram, error) {
-	if len(raw) < 13 {
+	if len(raw) < linkHeaderLen {
 		return Telegram{}, fmt.Errorf("telegram too short: %d bytes", len(raw))
 	}
 	length := raw[0]
@@ -50,80 +65,168 @@ func Parse(raw []byte) (Telegram, error) {
 	copy(t.MeterID[:], raw[4:8])
 	t.Version = raw[8]
 	t.DeviceType = raw[9]
+	if len(raw) == linkHeaderLen {
+		// Link-only frames such as ACK or ACC_DMD end after the address.
+		t.StatusFlags = map[string]bool{}
+		return t, nil
+	}
 	t.CI = raw[10]
-	cursor := 13
-	t.AccessNumber = raw[11]
-	t.Status = raw[12]
-	t.StatusFlags = decodeStatusFlags(t.Status)
+
+	if !isELL(t.CI) {
+		if err := parseTransport(&t, raw, 10); err != nil {
+			return Telegram{}, err
+		}
+		return t, nil
+	}
+	ell, consumed, err := parseELL(raw, 10)
+	if err != nil {
+		return Telegram{}, err
+	}
+	t.ELL = ell
+	t.AccessNumber = ell.AccessNumber
+	t.StatusFlags = map[string]bool{}
+	cursor := 10 + consumed
+	if ell.Encrypted() || cursor >= len(raw) {
+		t.Payload = raw[cursor:]
+		return t, nil
+	}
+	if err := parseTransport(&t, raw, cursor); err != nil {
+		return Telegram{}, err
+	}
+	return t, nil
+}
+
+// ParseTransport decodes the CI field at the start of data and the transport
+// layer behind it. It is used once an encrypted Extended Link Layer payload
+// has been decrypted.
+func ParseTransport(t *Telegram, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("transport layer missing after ELL")
+	}
+	return parseTransport(t, data, 0)
+}
+
+// parseTransport decodes the CI field found at offset together with the
+// transport header it announces and stores the remaining bytes as payload.
+func parseTransport(t *Telegram, data []byte, offset int) error {
+	ci := data[offset]
+	if ci == ciAFL {
+		afl, consumed, err := parseAFL(data, offset)
+		if err != nil {
+			return err
+		}
+		t.AFL = afl
+		if offset+consumed >= len(data) {
+			return fmt.Errorf("transport layer missing after AFL")
+		}
+		return parseTransport(t, data, offset+consumed)
+	}
+	t.AppCI = ci
+	start := offset + 1
+	cursor := start
 
 	var tpl TPLInfo
-	if needsShortTPL(t.CI) {
-		if shortTPLPresent(raw, 11) {
-			parsed, consumed, err := parseShortTPL(raw, 11)
+	switch {
+	case needsLongTPL(ci):
+		parsed, addr, consumed, err := parseLongTPL(data, start)
+		if err != nil {
+			return err
+		}
+		tpl = parsed
+		t.AppAddress = &addr
+		t.AccessNumber = tpl.AccessField
+		t.Status = Status(tpl.StatusField)
+		t.StatusFlags = t.Status.Flags(nil)
+		cursor = start + consumed
+	case needsShortTPL(ci):
+		if shortTPLPresent(data, start) {
+			parsed, consumed, err := parseShortTPL(data, start)
 			if err != nil {
-				return Telegram{}, err
+				return err
 			}
 			tpl = parsed
-			cursor = 11 + consumed
-		} else {
+			t.AccessNumber = tpl.AccessField
+			t.Status = Status(tpl.StatusField)
+			t.StatusFlags = t.Status.Flags(nil)
+			cursor = start + consumed
+		} else if !t.ELL.Present {
 			t.AccessNumber = 0
 			t.Status = 0
 			t.StatusFlags = map[string]bool{}
-			cursor = 11
 		}
+	case withoutTPL(ci):
+		if t.StatusFlags == nil {
+			t.StatusFlags = map[string]bool{}
+		}
+	default:
+		if len(data) < start+2 {
+			return fmt.Errorf("header for CI 0x%02X truncated", ci)
+		}
+		t.AccessNumber = data[start]
+		t.Status = Status(data[start+1])
+		t.StatusFlags = t.Status.Flags(nil)
+		cursor = start + 2
 	}
-	if cursor > len(raw) {
-		return Telegram{}, fmt.Errorf("payload offset %d exceeds telegram length %d", cursor, len(raw))
+	if cursor > len(data) {
+		return fmt.Errorf("payload offset %d exceeds telegram length %d", cursor, len(data))
 	}
+	tpl.Raw = data[offset:]
 	t.TPL = tpl
-	t.Payload = raw[cursor:]
-	return t, nil
+	t.Payload = data[cursor:]
+	return nil
 }
 
-// MeterIDString returns the EN 13757 display format (MSB first).
-func (t Telegram) MeterIDString() string {
-	return fmt.Sprintf("%02X%02X%02X%02X", t.MeterID[3], t.MeterID[2], t.MeterID[1], t.MeterID[0])
+// LinkAddress returns the address transmitted in the link-layer header.
+func (t Telegram) LinkAddress() Address {
+	return Address{
+		Manufacturer: t.Manufacturer,
+		ID:           t.MeterID,
+		Version:      t.Version,
+		DeviceType:   t.DeviceType,
+	}
 }
 
-var statusFlagDefs = []struct {
-	mask byte
-	key  string
-}{
-	{0x80, "status_empty_pipe"},
-	{0x40, "status_reverse_flow"},
-	{0x20, "status_freezing"},
-	{0x10, "status_temp_alarm"},
-	{0x08, "status_perm_alarm"},
-	{0x04, "status_battery_alarm"},
-	{0x02, "status_hw_alarm"},
+// Identity returns the application-layer address when a long transport header
+// is present and falls back to the link-layer address otherwise.
+func (t Telegram) Identity() Address {
+	if t.AppAddress != nil {
+		return *t.AppAddress
+	}
+	return t.LinkAddress()
 }
 
-func decodeStatusFlags(status byte) map[string]bool {
-	flags := make(map[string]bool)
-	for _, def := range statusFlagDefs {
-		if status&def.mask != 0 {
-			flags[def.key] = true
-		}
-	}
-	return flags
+// ManufacturerCode returns the three-letter FLAG code of the meter identity.
+func (t Telegram) ManufacturerCode() string {
+	return t.Identity().ManufacturerCode()
 }
 
-func parseShortTPL(data []byte, offset int) (TPLInfo, int, error) {
-	if len(data) < offset+4 {
-		return TPLInfo{}, 0, fmt.Errorf("short TPL header truncated")
-	}
-	tpl := TPLInfo{
-		Present:     true,
-		AccessField: data[offset],
-		StatusField: data[offset+1],
+// MeterIDString returns the EN 13757 display format (MSB first) of the meter
+// identity.
+func (t Telegram) MeterIDString() string {
+	return t.Identity().IDString()
+}
+
+// IDString returns the EN 13757 display format (MSB first).
+func (a Address) IDString() string {
+	return fmt.Sprintf("%02X%02X%02X%02X", a.ID[3], a.ID[2], a.ID[1], a.ID[0])
+}
+
+// parseLongTPL decodes the 12-byte long header: ID, manufacturer, version and
+// device type followed by the short header fields.
+func parseLongTPL(data []byte, offset int) (TPLInfo, Address, int, error) {
+	if len(data) < offset+12 {
+		return TPLInfo{}, Address{}, 0, fmt.Errorf("long TPL header truncated")
 	}
-	cfg := binary.LittleEndian.Uint16(data[offset+2 : offset+4])
-	tpl.Config = cfg
-	tpl.SecurityMode = byte((cfg >> 8) & 0x1F)
-	if tpl.SecurityMode == 5 {
-		tpl.EncryptedBlocks = int((cfg >> 4) & 0x0F)
+	var addr Address
+	copy(addr.ID[:], data[offset:offset+4])
+	addr.Manufacturer = binary.LittleEndian.Uint16(data[offset+4 : offset+6])
+	addr.Version = data[offset+6]
+	addr.DeviceType = data[offset+7]
+	tpl, consumed, err := parseShortTPL(data, offset+8)
+	if err != nil {
+		return TPLInfo{}, Address{}, 0, err
 	}
-	return tpl, 4, nil
+	return tpl, addr, 8 + consumed, nil
 }
 
 func shortTPLPresent(data []byte, offset int) bool {
@@ -137,5 +240,13 @@ func shortTPLPresent(data []byte, offset int) bool {
 }
 
 func needsShortTPL(ci byte) bool {
-	return ci == 0x7A
+	return ci == 0x7A || ci == ciAppErrorShort || ci == ciAlarmShort
+}
+
+func needsLongTPL(ci byte) bool {
+	return ci == 0x72 || ci == ciAppErrorLong || ci == ciAlarmLong
+}
+
+func withoutTPL(ci byte) bool {
+	return ci == 0x78 || ci == ciAppErrorNone || ci == ciAlarmNone
 }
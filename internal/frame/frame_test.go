@@ -2,6 +2,8 @@ package frame
 
 import (
 	"encoding/hex"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -22,6 +24,214 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseLongTPL(t *testing.T) {
+	raw := decodeHex(t, "4E442D2C4433221101317286868686B4091307F00000000C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B3000000")
+	tg, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if tg.AppAddress == nil {
+		t.Fatalf("long header not decoded")
+	}
+	if got := tg.LinkAddress().IDString(); got != "11223344" {
+		t.Fatalf("link id mismatch: %s", got)
+	}
+	id := tg.Identity()
+	if id.Manufacturer != 0x09B4 || id.Version != 0x13 || id.DeviceType != 0x07 {
+		t.Fatalf("unexpected identity %+v", id)
+	}
+	if got := tg.MeterIDString(); got != "86868686" {
+		t.Fatalf("meter id mismatch: %s", got)
+	}
+	if tg.AccessNumber != 0xF0 || !tg.TPL.Present {
+		t.Fatalf("unexpected TPL %+v access 0x%02X", tg.TPL, tg.AccessNumber)
+	}
+	if len(tg.Payload) == 0 || tg.Payload[0] != 0x0C {
+		t.Fatalf("payload does not start at first record: % X", tg.Payload)
+	}
+}
+
+func TestParseELL(t *testing.T) {
+	raw := decodeHex(t, "2C44B409381317051A0D8C00497A76000000046D25AA153A0C03000000000C13000000000F6400000000000000")
+	tg, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !tg.ELL.Present || tg.ELL.CI != 0x8C || tg.ELL.AccessNumber != 0x49 {
+		t.Fatalf("unexpected ELL %+v", tg.ELL)
+	}
+	if tg.CI != 0x8C || tg.AppCI != 0x7A {
+		t.Fatalf("unexpected CI chain 0x%02X -> 0x%02X", tg.CI, tg.AppCI)
+	}
+	if tg.AccessNumber != 0x76 || tg.Status != 0x00 {
+		t.Fatalf("unexpected TPL access 0x%02X status 0x%02X", tg.AccessNumber, tg.Status)
+	}
+	if len(tg.Payload) < 2 || tg.Payload[0] != 0x04 || tg.Payload[1] != 0x6D {
+		t.Fatalf("payload does not start at first record: % X", tg.Payload)
+	}
+}
+
+func TestParseELLSession(t *testing.T) {
+	plain := decodeHex(t, "1D44B409381317051A0D8D204900000000AABB7A76000000046D25AA153A")
+	tg, err := Parse(plain)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !tg.ELL.HasSession || tg.ELL.Encrypted() || tg.ELL.PayloadCRC != 0xBBAA {
+		t.Fatalf("unexpected ELL %+v", tg.ELL)
+	}
+	if tg.AppCI != 0x7A || tg.Payload[0] != 0x04 {
+		t.Fatalf("unexpected chained payload % X", tg.Payload)
+	}
+
+	encrypted := decodeHex(t, "1E44B409381317051A0D8D2049150000201122334455667788990011223344")
+	tg, err = Parse(encrypted)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !tg.ELL.Encrypted() || tg.ELL.Session() != 0x05 {
+		t.Fatalf("unexpected ELL %+v", tg.ELL)
+	}
+	if tg.AppCI != 0 || len(tg.Payload) != 14 {
+		t.Fatalf("encrypted payload not preserved: % X", tg.Payload)
+	}
+}
+
+func TestParseAFL(t *testing.T) {
+	raw := decodeHex(t, "5244B409868686861307900200007AF00040052F2F0C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000002F2F2F2F2F2F")
+	tg, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !tg.AFL.Present || tg.AFL.MoreFragments() {
+		t.Fatalf("unexpected AFL %+v", tg.AFL)
+	}
+	if tg.CI != 0x90 || tg.AppCI != 0x7A || tg.AccessNumber != 0xF0 {
+		t.Fatalf("unexpected chain CI 0x%02X -> 0x%02X access 0x%02X", tg.CI, tg.AppCI, tg.AccessNumber)
+	}
+}
+
+func TestParseAFLFragmented(t *testing.T) {
+	raw := decodeHex(t, "1644B409868686861307900201407AF00000002F2F0C13")
+	_, err := Parse(raw)
+	if !errors.Is(err, ErrFragmented) {
+		t.Fatalf("expected ErrFragmented, got %v", err)
+	}
+}
+
+func TestParseWired(t *testing.T) {
+	raw := decodeHex(t, "6847476808057286868686B4091307F00000000C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000004316")
+	if !IsWiredLongFrame(raw) {
+		t.Fatalf("wired frame not detected")
+	}
+	tg, err := ParseWired(raw)
+	if err != nil {
+		t.Fatalf("ParseWired: %v", err)
+	}
+	if !tg.Wired || tg.Control != 0x08 || tg.PrimaryAddress != 0x05 || tg.CI != 0x72 {
+		t.Fatalf("unexpected link layer %+v", tg)
+	}
+	if got := tg.MeterIDString(); got != "86868686" {
+		t.Fatalf("meter id mismatch: %s", got)
+	}
+	if tg.Payload[0] != 0x0C || tg.Payload[len(tg.Payload)-1] != 0x00 {
+		t.Fatalf("unexpected payload % X", tg.Payload)
+	}
+
+	raw[10] ^= 0xFF
+	if _, err := ParseWired(raw); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("expected ErrChecksum, got %v", err)
+	}
+}
+
+func TestCRC16(t *testing.T) {
+	if got := CRC16([]byte("123456789")); got != 0xC2B7 {
+		t.Fatalf("unexpected CRC 0x%04X", got)
+	}
+}
+
+func TestStripCRCFormatA(t *testing.T) {
+	plain := decodeHex(t, "2C44B409381317051A0D8C00497A76000000046D25AA153A0C03000000000C13000000000F6400000000000000")
+	raw := withFormatACRC(plain)
+	got, err := StripCRCFormatA(raw)
+	if err != nil {
+		t.Fatalf("StripCRCFormatA: %v", err)
+	}
+	if hex.EncodeToString(got) != hex.EncodeToString(plain) {
+		t.Fatalf("unexpected frame % X", got)
+	}
+	auto, err := StripCRCAuto(raw)
+	if err != nil || len(auto) != len(plain) {
+		t.Fatalf("StripCRCAuto: %v (%d bytes)", err, len(auto))
+	}
+
+	raw[20] ^= 0x01
+	_, err = StripCRCFormatA(raw)
+	var crcErr *CRCError
+	if !errors.As(err, &crcErr) || crcErr.Block != 2 {
+		t.Fatalf("expected CRCError in block 2, got %v", err)
+	}
+}
+
+func TestStripCRCFormatB(t *testing.T) {
+	plain := decodeHex(t, "2C44B409381317051A0D8C00497A76000000046D25AA153A0C03000000000C13000000000F6400000000000000")
+	raw := append([]byte{}, plain...)
+	raw[0] += 2
+	crc := CRC16(raw)
+	raw = append(raw, byte(crc>>8), byte(crc))
+	got, err := StripCRCFormatB(raw)
+	if err != nil {
+		t.Fatalf("StripCRCFormatB: %v", err)
+	}
+	if hex.EncodeToString(got) != hex.EncodeToString(plain) {
+		t.Fatalf("unexpected frame % X", got)
+	}
+	auto, err := StripCRCAuto(plain)
+	if err != nil || len(auto) != len(plain) {
+		t.Fatalf("StripCRCAuto changed a frame without CRCs: %v", err)
+	}
+}
+
+func TestStripCRCAutoFormatBMismatch(t *testing.T) {
+	// A 150-byte format B frame: block 2 ends at byte 126, block 3 follows.
+	raw := make([]byte, 150)
+	raw[0] = 149
+	raw[1] = 0x44
+	crc := CRC16(raw[:126])
+	raw[126], raw[127] = byte(crc>>8), byte(crc)
+	crc = CRC16(raw[128:148])
+	raw[148], raw[149] = byte(crc>>8), byte(crc)
+	if _, err := StripCRCAuto(raw); err != nil {
+		t.Fatalf("StripCRCAuto: %v", err)
+	}
+
+	raw[140] ^= 0xFF
+	_, err := StripCRCAuto(raw)
+	var crcErr *CRCError
+	if !errors.As(err, &crcErr) || crcErr.Format != "B" || crcErr.Block != 3 {
+		t.Fatalf("expected format B block 3 CRCError, got %v", err)
+	}
+}
+
+// withFormatACRC inserts format A block CRCs into a frame without them.
+func withFormatACRC(plain []byte) []byte {
+	var out []byte
+	blockLen := 10
+	for pos := 0; pos < len(plain); pos += blockLen {
+		if pos > 0 {
+			blockLen = 16
+		}
+		end := pos + blockLen
+		if end > len(plain) {
+			end = len(plain)
+		}
+		crc := CRC16(plain[pos:end])
+		out = append(out, plain[pos:end]...)
+		out = append(out, byte(crc>>8), byte(crc))
+	}
+	return out
+}
+
 func decodeHex(t *testing.T, s string) []byte {
 	t.Helper()
 	b, err := hex.DecodeString(s)
@@ -30,3 +240,176 @@ func decodeHex(t *testing.T, s string) []byte {
 	}
 	return b
 }
+
+func TestManufacturerCode(t *testing.T) {
+	if code := ManufacturerCode(0x09B4); code != "BMT" {
+		t.Fatalf("expected BMT, got %s", code)
+	}
+	if name := ManufacturerName(0x2C2D); name != "Kamstrup" {
+		t.Fatalf("expected Kamstrup for KAM, got %q", name)
+	}
+	for _, code := range []string{"BMT", "KAM", "ZRI", "abb"} {
+		id, err := ManufacturerID(code)
+		if err != nil {
+			t.Fatalf("encode %s: %v", code, err)
+		}
+		if back := ManufacturerCode(id); back != strings.ToUpper(code) {
+			t.Fatalf("round trip %s: got %s", code, back)
+		}
+	}
+	if _, err := ManufacturerID("B1T"); err == nil {
+		t.Fatalf("expected error for non-letter code")
+	}
+	if code := ManufacturerCode(0x0000); code != "???" {
+		t.Fatalf("expected ??? for invalid field, got %s", code)
+	}
+}
+
+func TestCatalogue(t *testing.T) {
+	names := map[byte]string{0x02: "electricity", 0x07: "water", 0x08: "heat cost allocation", 0x1A: "smoke detector", 0x3F: "reserved (0x3F)"}
+	for dt, want := range names {
+		if got := DeviceTypeName(dt); got != want {
+			t.Fatalf("device type 0x%02X: expected %q, got %q", dt, want, got)
+		}
+	}
+	cis := map[byte]string{0x7A: "RSP_UD (short TPL)", 0x8D: "ELL II", 0x90: "AFL", 0x70: "application error (no TPL)", 0xA2: "manufacturer specific (0xA2)"}
+	for ci, want := range cis {
+		if got := CIName(ci); got != want {
+			t.Fatalf("CI 0x%02X: expected %q, got %q", ci, want, got)
+		}
+	}
+}
+
+func TestDecodeControl(t *testing.T) {
+	cases := map[byte]FrameKind{
+		0x44: FrameSndNR,
+		0x46: FrameSndIR,
+		0x47: FrameAccNR,
+		0x48: FrameAccDmd,
+		0x40: FrameSndNke,
+		0x53: FrameSndUD,
+		0x73: FrameSndUD,
+		0x5B: FrameReqUD2,
+		0x7B: FrameReqUD2,
+		0x00: FrameAck,
+		0x06: FrameCnfIR,
+		0x08: FrameRspUD,
+		0x28: FrameRspUD,
+		0x4F: FrameUnknown,
+	}
+	for c, want := range cases {
+		if got := DecodeControl(c); got != want {
+			t.Fatalf("C 0x%02X: expected %s, got %s", c, want, got)
+		}
+	}
+}
+
+func TestParseLinkOnly(t *testing.T) {
+	tg, err := Parse(decodeHex(t, "0948B409868686861307"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if tg.Kind() != FrameAccDmd || tg.HasApplicationData() || tg.MeterIDString() != "86868686" {
+		t.Fatalf("unexpected link-only telegram %+v", tg)
+	}
+}
+
+func TestParseWiredShort(t *testing.T) {
+	tg, err := ParseWired(decodeHex(t, "107BFE7916"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if tg.Kind() != FrameReqUD2 || tg.PrimaryAddress != 0xFE {
+		t.Fatalf("unexpected short frame %+v", tg)
+	}
+	if _, err := ParseWired(decodeHex(t, "107BFE7A16")); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	ack, err := ParseWired([]byte{0xE5})
+	if err != nil || ack.Kind() != FrameAck {
+		t.Fatalf("expected ACK, got %v (%v)", ack.Kind(), err)
+	}
+}
+
+func TestParseApplicationError(t *testing.T) {
+	tg, err := Parse(decodeHex(t, "0B44B4098686868613077008"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	appErr, ok := tg.ApplicationError()
+	if !ok || appErr.Code != 8 || appErr.Description() != "application too busy for handling readout request" {
+		t.Fatalf("unexpected application error %+v (%v)", appErr, ok)
+	}
+	if _, ok := tg.Alarm(); ok {
+		t.Fatalf("application error reported as alarm")
+	}
+}
+
+func TestParseAlarm(t *testing.T) {
+	tg, err := Parse(decodeHex(t, "0F44B409868686861307742A00000005"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	alarm, ok := tg.Alarm()
+	if !ok || !alarm.Active() || tg.AccessNumber != 0x2A {
+		t.Fatalf("unexpected alarm %+v (%v)", alarm, ok)
+	}
+	if bits := alarm.Bits(); len(bits) != 2 || bits[0] != 0 || bits[1] != 2 {
+		t.Fatalf("unexpected alarm bits %v", bits)
+	}
+}
+
+func TestTPLConfig(t *testing.T) {
+	tg, err := Parse(decodeHex(t, "1044B4098686868613077A2A0036A52F2F"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	tpl := tg.TPL
+	if !tpl.Bidirectional() || tpl.Accessible() || !tpl.Synchronous() {
+		t.Fatalf("unexpected B/A/S bits in 0x%04X", tpl.Config)
+	}
+	if tpl.SecurityMode != SecurityAesCbcIV || tpl.EncryptedBlocks != 3 || tpl.Content() != 1 || tpl.HopCount() != 2 {
+		t.Fatalf("unexpected mode 5 fields %+v", tpl)
+	}
+
+	tg, err = Parse(decodeHex(t, "1144B4098686868613077A2A00100D052F2F"))
+	if err != nil {
+		t.Fatalf("parse mode 13: %v", err)
+	}
+	if tg.TPL.SecurityMode != SecurityTLS || tg.TPL.EncryptedBytes() != 0x10 || tg.TPL.ProtocolType() != 5 || len(tg.Payload) != 2 {
+		t.Fatalf("unexpected mode 13 fields %+v", tg.TPL)
+	}
+
+	// Modes 8, 9 and 10 keep the low configuration byte raw and carry no CFE.
+	for _, mode := range []string{"88", "89", "8A"} {
+		tg, err = Parse(decodeHex(t, "1044B4098686868613077A2A0037"+mode+"2F2F"))
+		if err != nil {
+			t.Fatalf("parse mode 0x%s: %v", mode, err)
+		}
+		tpl := tg.TPL
+		if !tpl.Bidirectional() || tpl.SecurityMode != byte(tpl.Config>>8)&0x1F || tpl.Config&0xFF != 0x37 {
+			t.Fatalf("unexpected config for 0x%s: %+v", mode, tpl)
+		}
+		if !tpl.SecurityModeSupported() || tpl.EncryptedBlocks != 0 || tpl.Content() != 0 || tpl.HopCount() != 0 ||
+			tpl.EncryptedBytes() != 0 || tpl.KeyID() != 0 || tpl.ConfigExt != 0 || len(tg.Payload) != 2 {
+			t.Fatalf("unexpected mode fields for 0x%s: %+v payload %X", mode, tpl, tg.Payload)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s := Status(0x2D)
+	if !s.ApplicationBusy() || !s.PowerLow() || !s.PermanentError() || s.TemporaryError() {
+		t.Fatalf("unexpected standard bits for 0x%02X", byte(s))
+	}
+	if text := s.Text(StatusBits{0x20: "leak"}); text != "APPLICATION_BUSY POWER_LOW PERMANENT_ERROR LEAK" {
+		t.Fatalf("unexpected status text %q", text)
+	}
+	flags := s.Flags(nil)
+	if !flags["status_power_low"] || !flags["status_manufacturer_0x20"] || len(flags) != 4 {
+		t.Fatalf("unexpected status flags %v", flags)
+	}
+	if text := Status(0).Text(nil); text != "OK" {
+		t.Fatalf("expected OK, got %q", text)
+	}
+}
@@ -0,0 +1,96 @@
+package frame
+
+import (
+	"fmt"
+	"strings"
+)
+
+// manufacturerNames maps EN 13757 / DLMS UA FLAG codes to vendor names.
+var manufacturerNames = map[string]string{
+	"ABB": "ABB",
+	"ACW": "Itron",
+	"AMT": "Aquametro",
+	"APA": "Apator",
+	"BMT": "BMETERS",
+	"DAN": "Danfoss",
+	"DME": "Diehl Metering",
+	"DWZ": "Lorenz",
+	"EFE": "Engelmann",
+	"ELS": "Elster",
+	"ELV": "Elvaco",
+	"EMH": "EMH metering",
+	"ESY": "EasyMeter",
+	"GAV": "Carlo Gavazzi",
+	"GWF": "GWF MessSysteme",
+	"HYD": "Diehl Metering (Hydrometer)",
+	"IST": "ista",
+	"ITW": "Itron",
+	"KAM": "Kamstrup",
+	"LGB": "Landis+Gyr",
+	"LUG": "Landis+Gyr",
+	"MAD": "Maddalena",
+	"NZR": "Nordwestdeutsche Zählerrevision",
+	"QDS": "Qundis",
+	"REL": "Relay",
+	"SEN": "Sensus",
+	"SON": "Sontex",
+	"SPX": "Sensus (Spanner-Pollux)",
+	"TCH": "Techem",
+	"WEH": "Wehrle",
+	"ZRI": "Zenner",
+}
+
+// ManufacturerCode decodes the three-letter FLAG code packed into the
+// manufacturer field (three 5-bit letters, 'A' = 1). Letters outside A-Z are
+// rendered as '?'.
+func ManufacturerCode(id uint16) string {
+	code := make([]byte, 3)
+	for i := range code {
+		letter := (id >> (10 - 5*i)) & 0x1F
+		if letter < 1 || letter > 26 {
+			code[i] = '?'
+			continue
+		}
+		code[i] = byte('A' + letter - 1)
+	}
+	return string(code)
+}
+
+// ManufacturerID packs a three-letter FLAG code into the manufacturer field.
+func ManufacturerID(code string) (uint16, error) {
+	upper := strings.ToUpper(code)
+	if len(upper) != 3 {
+		return 0, fmt.Errorf("manufacturer code %q must have three letters", code)
+	}
+	var id uint16
+	for i := 0; i < 3; i++ {
+		c := upper[i]
+		if c < 'A' || c > 'Z' {
+			return 0, fmt.Errorf("manufacturer code %q contains non-letter %q", code, c)
+		}
+		id = id<<5 | uint16(c-'A'+1)
+	}
+	return id, nil
+}
+
+// MustManufacturerID is like ManufacturerID but panics on invalid codes. It
+// is meant for driver detection rules declared at package level.
+func MustManufacturerID(code string) uint16 {
+	id, err := ManufacturerID(code)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// ManufacturerName returns the vendor name registered for the manufacturer
+// field, or an empty string when it is not known.
+func ManufacturerName(id uint16) string {
+	return manufacturerNames[ManufacturerCode(id)]
+}
+
+// ManufacturerCode returns the three-letter FLAG code of the address.
+func (a Address) ManufacturerCode() string { return ManufacturerCode(a.Manufacturer) }
+
+// ManufacturerName returns the vendor name of the address, if known.
+func (a Address) ManufacturerName() string { return ManufacturerName(a.Manufacturer) }
@@ -0,0 +1,113 @@
+package frame
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the status byte of the transport header. EN 13757-7 defines
+// bits 0-4 for every meter; bits 5-7 are manufacturer specific.
+type Status byte
+
+const (
+	statusApplicationMask = 0x03
+	statusPowerLow        = 0x04
+	statusPermanentError  = 0x08
+	statusTemporaryError  = 0x10
+	statusManufacturer    = 0xE0
+
+	applicationBusy     = 0x01
+	applicationError    = 0x02
+	applicationAbnormal = 0x03
+)
+
+// StatusBits names manufacturer-specific status bits (0x20, 0x40, 0x80) for
+// a meter family. Drivers supply them through driver.StatusNamer.
+type StatusBits map[byte]string
+
+// ApplicationBusy reports application status 01.
+func (s Status) ApplicationBusy() bool { return s&statusApplicationMask == applicationBusy }
+
+// ApplicationError reports application status 10: any application error.
+func (s Status) ApplicationError() bool { return s&statusApplicationMask == applicationError }
+
+// AbnormalCondition reports application status 11: an alarm condition.
+func (s Status) AbnormalCondition() bool {
+	return s&statusApplicationMask == applicationAbnormal
+}
+
+// PowerLow reports bit 2: the battery or supply is running low.
+func (s Status) PowerLow() bool { return s&statusPowerLow != 0 }
+
+// PermanentError reports bit 3: the meter needs service.
+func (s Status) PermanentError() bool { return s&statusPermanentError != 0 }
+
+// TemporaryError reports bit 4: a transient error such as a sensor fault.
+func (s Status) TemporaryError() bool { return s&statusTemporaryError != 0 }
+
+// ManufacturerBits returns bits 5-7 in place.
+func (s Status) ManufacturerBits() byte { return byte(s) & statusManufacturer }
+
+var standardStatusFlags = []struct {
+	set  func(Status) bool
+	flag string
+	text string
+}{
+	{Status.ApplicationBusy, "status_application_busy", "APPLICATION_BUSY"},
+	{Status.ApplicationError, "status_application_error", "APPLICATION_ERROR"},
+	{Status.AbnormalCondition, "status_abnormal_condition", "ABNORMAL_CONDITION"},
+	{Status.PowerLow, "status_power_low", "POWER_LOW"},
+	{Status.PermanentError, "status_permanent_error", "PERMANENT_ERROR"},
+	{Status.TemporaryError, "status_temporary_error", "TEMPORARY_ERROR"},
+}
+
+// Flags returns the set status conditions keyed by field name. Manufacturer
+// bits use the names in bits, or "status_manufacturer_0xNN" when unnamed.
+func (s Status) Flags(bits StatusBits) map[string]bool {
+	flags := make(map[string]bool)
+	for _, def := range standardStatusFlags {
+		if def.set(s) {
+			flags[def.flag] = true
+		}
+	}
+	for _, mask := range manufacturerMasks(s) {
+		if name, ok := bits[mask]; ok {
+			flags["status_"+strings.ToLower(name)] = true
+		} else {
+			flags[fmt.Sprintf("status_manufacturer_0x%02X", mask)] = true
+		}
+	}
+	return flags
+}
+
+// Text renders the status as "OK" or a space separated list of conditions,
+// e.g. "POWER_LOW PERMANENT_ERROR".
+func (s Status) Text(bits StatusBits) string {
+	var parts []string
+	for _, def := range standardStatusFlags {
+		if def.set(s) {
+			parts = append(parts, def.text)
+		}
+	}
+	for _, mask := range manufacturerMasks(s) {
+		if name, ok := bits[mask]; ok {
+			parts = append(parts, strings.ToUpper(name))
+		} else {
+			parts = append(parts, fmt.Sprintf("MANUFACTURER_0x%02X", mask))
+		}
+	}
+	if len(parts) == 0 {
+		return "OK"
+	}
+	return strings.Join(parts, " ")
+}
+
+func manufacturerMasks(s Status) []byte {
+	var masks []byte
+	for _, mask := range []byte{0x20, 0x40, 0x80} {
+		if byte(s)&mask != 0 {
+			masks = append(masks, mask)
+		}
+	}
+	return masks
+}
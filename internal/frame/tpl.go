@@ -0,0 +1,160 @@
+package frame
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Security modes of the TPL configuration field (EN 13757-7).
+//
+// Field coverage per mode: the B, A and S bits and the mode number are
+// decoded for every mode. Modes 0, 5 and 7 add the encrypted block count,
+// content and hop counter, mode 7 the KDF and key id from its CFE, and mode
+// 13 the encrypted byte count and the protocol type from its CFE. For modes
+// 8, 9 and 10 nothing beyond the mode number is decoded: the accessors
+// return zero, the low configuration byte is only available raw in Config,
+// and no CFE byte is consumed. crypto.Decrypt rejects these modes.
+const (
+	SecurityNone       = 0
+	SecurityAesCbcIV   = 5
+	SecurityAesCbcNoIV = 7
+	SecurityAesCtrCmac = 8
+	SecurityAesGcm     = 9
+	SecurityAesCcm     = 10
+	SecurityTLS        = 13
+)
+
+const (
+	securityModeMask    = 0x1F
+	securityModeShift   = 8
+	configBidirectional = 0x8000
+	configAccessibility = 0x4000
+	configSynchronous   = 0x2000
+)
+
+// TPLInfo describes the short or long transport header.
+type TPLInfo struct {
+	Present     bool
+	AccessField byte
+	StatusField byte
+	Config      uint16
+	// ConfigExt is the configuration field extension (CFE) that follows the
+	// configuration word in security modes 7 and 13.
+	ConfigExt       byte
+	SecurityMode    byte
+	EncryptedBlocks int
+	// Raw spans the CI field, header and payload as transmitted; the AFL MAC
+	// is computed over these bytes.
+	Raw []byte
+}
+
+// Bidirectional reports the B bit: the meter accepts commands.
+func (p TPLInfo) Bidirectional() bool { return p.Config&configBidirectional != 0 }
+
+// Accessible reports the A bit: the meter listens after transmitting.
+func (p TPLInfo) Accessible() bool { return p.Config&configAccessibility != 0 }
+
+// Synchronous reports the S bit: the telegram is sent on a fixed schedule.
+func (p TPLInfo) Synchronous() bool { return p.Config&configSynchronous != 0 }
+
+// hasBlockLayout reports the modes whose low configuration byte carries the
+// encrypted block count, content and hop counter fields.
+func (p TPLInfo) hasBlockLayout() bool {
+	switch p.SecurityMode {
+	case SecurityNone, SecurityAesCbcIV, SecurityAesCbcNoIV:
+		return true
+	default:
+		return false
+	}
+}
+
+// Content returns the C field (bits 2-3) for modes 0, 5 and 7: 0 standard
+// data, 1 static data, 2 and 3 reserved.
+func (p TPLInfo) Content() byte {
+	if !p.hasBlockLayout() {
+		return 0
+	}
+	return byte(p.Config>>2) & 0x03
+}
+
+// HopCount returns the repeater hop counter (bits 0-1) for modes 0, 5 and 7.
+func (p TPLInfo) HopCount() byte {
+	if !p.hasBlockLayout() {
+		return 0
+	}
+	return byte(p.Config) & 0x03
+}
+
+// EncryptedBytes returns the number of encrypted bytes announced by the low
+// configuration byte in mode 13.
+func (p TPLInfo) EncryptedBytes() int {
+	if p.SecurityMode != SecurityTLS {
+		return 0
+	}
+	return int(p.Config & 0xFF)
+}
+
+// KDF returns the key derivation function selected by the CFE in mode 7;
+// 1 is the OMS CMAC derivation.
+func (p TPLInfo) KDF() byte {
+	if p.SecurityMode != SecurityAesCbcNoIV {
+		return 0
+	}
+	return (p.ConfigExt >> 4) & 0x03
+}
+
+// KeyID returns the key version announced by the CFE in mode 7.
+func (p TPLInfo) KeyID() byte {
+	if p.SecurityMode != SecurityAesCbcNoIV {
+		return 0
+	}
+	return p.ConfigExt & 0x0F
+}
+
+// ProtocolType returns the protocol announced by the CFE in mode 13.
+func (p TPLInfo) ProtocolType() byte {
+	if p.SecurityMode != SecurityTLS {
+		return 0
+	}
+	return p.ConfigExt & 0x0F
+}
+
+// SecurityModeSupported reports whether the security mode is defined by
+// EN 13757-7 (0, 5, 7, 8, 9, 10 and 13).
+func (p TPLInfo) SecurityModeSupported() bool {
+	switch p.SecurityMode {
+	case SecurityNone, SecurityAesCbcIV, SecurityAesCbcNoIV, SecurityAesCtrCmac, SecurityAesGcm, SecurityAesCcm, SecurityTLS:
+		return true
+	default:
+		return false
+	}
+}
+
+func parseShortTPL(data []byte, offset int) (TPLInfo, int, error) {
+	if len(data) < offset+4 {
+		return TPLInfo{}, 0, fmt.Errorf("short TPL header truncated")
+	}
+	tpl := TPLInfo{
+		Present:     true,
+		AccessField: data[offset],
+		StatusField: data[offset+1],
+	}
+	cfg := binary.LittleEndian.Uint16(data[offset+2 : offset+4])
+	tpl.Config = cfg
+	tpl.SecurityMode = byte(cfg>>securityModeShift) & securityModeMask
+	consumed := 4
+	switch tpl.SecurityMode {
+	case SecurityAesCbcIV:
+		tpl.EncryptedBlocks = int((cfg >> 4) & 0x0F)
+	case SecurityAesCbcNoIV, SecurityTLS:
+		if len(data) < offset+5 {
+			return TPLInfo{}, 0, fmt.Errorf("configuration field extension truncated")
+		}
+		if tpl.SecurityMode == SecurityAesCbcNoIV {
+			tpl.EncryptedBlocks = int((cfg >> 4) & 0x0F)
+		}
+		tpl.ConfigExt = data[offset+4]
+		consumed++
+	}
+	return tpl, consumed, nil
+}
@@ -0,0 +1,100 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	wiredLongStart  = 0x68
+	wiredShortStart = 0x10
+	wiredStop       = 0x16
+	wiredAck        = 0xE5
+)
+
+// ErrChecksum reports a wired M-Bus frame whose checksum byte does not match.
+var ErrChecksum = errors.New("wired M-Bus checksum mismatch")
+
+// IsWiredLongFrame reports whether raw is framed as a wired M-Bus long frame
+// (0x68 L L 0x68 ... CS 0x16). The length check keeps wireless telegrams
+// whose L-field happens to be 0x68 from matching.
+func IsWiredLongFrame(raw []byte) bool {
+	if len(raw) < 6 || raw[0] != wiredLongStart || raw[3] != wiredLongStart {
+		return false
+	}
+	return raw[1] == raw[2] && len(raw) == int(raw[1])+6
+}
+
+// IsWiredFrame reports whether raw is any wired M-Bus frame: the single
+// character acknowledgement 0xE5, a short frame (0x10 C A CS 0x16) or a long
+// frame.
+func IsWiredFrame(raw []byte) bool {
+	if len(raw) == 1 && raw[0] == wiredAck {
+		return true
+	}
+	if len(raw) == 5 && raw[0] == wiredShortStart && raw[4] == wiredStop {
+		return true
+	}
+	return IsWiredLongFrame(raw)
+}
+
+// ParseWired decodes a wired M-Bus frame (EN 13757-2). Single character and
+// short frames carry no application layer. In long frames the link layer
+// only carries C, the primary address and CI, so the meter identity comes
+// from the long transport header (CI 0x72).
+func ParseWired(raw []byte) (Telegram, error) {
+	switch {
+	case len(raw) == 1 && raw[0] == wiredAck:
+		// The single character has no C field; 0x00 decodes as ACK.
+		return Telegram{Raw: raw, Wired: true, StatusFlags: map[string]bool{}}, nil
+	case len(raw) == 5 && raw[0] == wiredShortStart:
+		return parseWiredShort(raw)
+	case !IsWiredLongFrame(raw):
+		return Telegram{}, fmt.Errorf("not a wired M-Bus frame")
+	}
+	length := int(raw[1])
+	if length < 3 {
+		return Telegram{}, fmt.Errorf("wired frame too short: L=%d", length)
+	}
+	if raw[len(raw)-1] != wiredStop {
+		return Telegram{}, fmt.Errorf("wired frame stop byte 0x%02X, want 0x%02X", raw[len(raw)-1], wiredStop)
+	}
+	body := raw[4 : 4+length]
+	var sum byte
+	for _, b := range body {
+		sum += b
+	}
+	if cs := raw[4+length]; cs != sum {
+		return Telegram{}, fmt.Errorf("%w: got 0x%02X, computed 0x%02X", ErrChecksum, cs, sum)
+	}
+	t := Telegram{
+		Raw:            raw,
+		Length:         raw[1],
+		Wired:          true,
+		Control:        body[0],
+		PrimaryAddress: body[1],
+		CI:             body[2],
+	}
+	if err := parseTransport(&t, body, 2); err != nil {
+		return Telegram{}, err
+	}
+	return t, nil
+}
+
+// parseWiredShort decodes 0x10 C A CS 0x16, used for SND_NKE and REQ_UD.
+func parseWiredShort(raw []byte) (Telegram, error) {
+	if raw[4] != wiredStop {
+		return Telegram{}, fmt.Errorf("wired frame stop byte 0x%02X, want 0x%02X", raw[4], wiredStop)
+	}
+	if sum := raw[1] + raw[2]; raw[3] != sum {
+		return Telegram{}, fmt.Errorf("%w: got 0x%02X, computed 0x%02X", ErrChecksum, raw[3], sum)
+	}
+	return Telegram{
+		Raw:            raw,
+		Length:         2,
+		Wired:          true,
+		Control:        raw[1],
+		PrimaryAddress: raw[2],
+		StatusFlags:    map[string]bool{},
+	}, nil
+}
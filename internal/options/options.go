@@ -5,11 +5,20 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 	"unicode"
 )
 
 type contextKey struct{}
 
+type receiveTimeKey struct{}
+
+type compatTimestampKey struct{}
+
+// PlaceholderTimestamp is the fixed timestamp wmbusmeters writes into its
+// golden outputs. Drivers report it when no receive time is set.
+const PlaceholderTimestamp = "1111-11-11T11:11:11Z"
+
 // WithSecurityKey stores the provided key inside the context.
 func WithSecurityKey(ctx context.Context, key []byte) context.Context {
 	if len(key) == 0 {
@@ -30,6 +39,40 @@ func SecurityKey(ctx context.Context) []byte {
 	return nil
 }
 
+// WithReceiveTime stores the time the telegram was received inside the
+// context. A zero time leaves the context unchanged.
+func WithReceiveTime(ctx context.Context, t time.Time) context.Context {
+	if t.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, receiveTimeKey{}, t)
+}
+
+// ReceiveTime retrieves the receive time from context if present.
+func ReceiveTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(receiveTimeKey{}).(time.Time)
+	return t, ok
+}
+
+// WithCompatTimestamp makes Timestamp report PlaceholderTimestamp even when
+// a receive time is set, for wmbusmeters golden compatibility.
+func WithCompatTimestamp(ctx context.Context) context.Context {
+	return context.WithValue(ctx, compatTimestampKey{}, true)
+}
+
+// Timestamp formats the receive time as RFC 3339 in UTC. It returns
+// PlaceholderTimestamp in compatibility mode or when the context carries no
+// receive time.
+func Timestamp(ctx context.Context) string {
+	if compat, _ := ctx.Value(compatTimestampKey{}).(bool); compat {
+		return PlaceholderTimestamp
+	}
+	if t, ok := ReceiveTime(ctx); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return PlaceholderTimestamp
+}
+
 // ParseKeyHex validates and decodes a 32-hex-digit AES key string.
 func ParseKeyHex(input string) ([]byte, error) {
 	if strings.TrimSpace(input) == "" {
@@ -0,0 +1,198 @@
+package records
+
+import "fmt"
+
+const (
+	difIdle          = 0x2F
+	difManufacturer  = 0x0F
+	difMoreRecords   = 0x1F
+	difGlobalReadout = 0x7F
+
+	vifPlainText    = 0x7C
+	vifManufacturer = 0x7F
+	vifExtensionFB  = 0xFB
+	vifExtensionFD  = 0xFD
+	vifExtensionEF  = 0xEF
+
+	dataVariable = 0x0D
+
+	maxExtensions = 10
+)
+
+// Standard decodes data records as defined by EN 13757-3.
+type Standard struct{}
+
+var _ Decoder = Standard{}
+
+// Decode is a shorthand for Standard{}.Decode.
+func Decode(payload []byte) ([]Record, error) {
+	return Standard{}.Decode(payload)
+}
+
+// Decode walks the payload and returns every data record. A manufacturer
+// specific tail (DIF 0x0F/0x1F) is returned as the final record.
+func (Standard) Decode(payload []byte) ([]Record, error) {
+	records := make([]Record, 0, 8)
+	i := 0
+	for i < len(payload) {
+		dif := payload[i]
+		i++
+		switch dif {
+		case difIdle, difGlobalReadout:
+			continue
+		case difManufacturer, difMoreRecords:
+			records = append(records, Record{DIF: dif, Data: payload[i:]})
+			return records, nil
+		}
+		rec := Record{DIF: dif, Function: int((dif >> 4) & 0x03)}
+		storage := int((dif >> 6) & 0x01)
+		hasDIFE := (dif & 0x80) != 0
+		for difenr := 0; hasDIFE; difenr++ {
+			if difenr == maxExtensions {
+				return nil, fmt.Errorf("more than %d DIFE bytes at offset %d", maxExtensions, i)
+			}
+			if i >= len(payload) {
+				return nil, fmt.Errorf("unexpected end of payload while reading DIFE")
+			}
+			dife := payload[i]
+			i++
+			rec.DIFE = append(rec.DIFE, dife)
+			rec.Subunit |= int((dife>>6)&0x01) << difenr
+			rec.Tariff |= int((dife>>4)&0x03) << (difenr * 2)
+			storage |= int(dife&0x0F) << (1 + difenr*4)
+			hasDIFE = (dife & 0x80) != 0
+		}
+		rec.Storage = storage
+
+		next, err := decodeVIF(payload, i, &rec)
+		if err != nil {
+			return nil, err
+		}
+		i = next
+
+		length, err := dataLength(dif)
+		if err != nil {
+			return nil, fmt.Errorf("%w at offset %d", err, i)
+		}
+		if rec.DataField() == dataVariable {
+			if i >= len(payload) {
+				return nil, fmt.Errorf("unexpected end of payload before LVAR")
+			}
+			rec.LVAR = payload[i]
+			i++
+			if length, err = LVARLength(rec.LVAR); err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i-1)
+			}
+		}
+		if i+length > len(payload) {
+			return nil, fmt.Errorf("payload truncated for DIF 0x%02X", dif)
+		}
+		rec.Data = payload[i : i+length]
+		i += length
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// decodeVIF reads the VIF, an optional plain-text unit and the VIFE chain
+// starting at offset and returns the offset of the data field.
+func decodeVIF(payload []byte, offset int, rec *Record) (int, error) {
+	i := offset
+	if i >= len(payload) {
+		return 0, fmt.Errorf("unexpected end of payload before VIF")
+	}
+	vif := payload[i]
+	i++
+	rec.RawVIF = append(rec.RawVIF, vif)
+	rec.VIF = int(vif & 0x7F)
+
+	if vif == vifExtensionFB || vif == vifExtensionFD || vif == vifExtensionEF {
+		if i >= len(payload) {
+			return 0, fmt.Errorf("unexpected end of payload after extension VIF 0x%02X", vif)
+		}
+		code := payload[i]
+		i++
+		rec.RawVIF = append(rec.RawVIF, code)
+		rec.VIF = int(vif)<<8 | int(code&0x7F)
+		vif = code
+	}
+
+	for extensions := 0; vif&0x80 != 0; extensions++ {
+		if extensions == maxExtensions {
+			return 0, fmt.Errorf("more than %d VIFE bytes at offset %d", maxExtensions, i)
+		}
+		if i >= len(payload) {
+			return 0, fmt.Errorf("unexpected end of payload while reading VIFE")
+		}
+		vif = payload[i]
+		i++
+		rec.RawVIF = append(rec.RawVIF, vif)
+		rec.VIFE = append(rec.VIFE, vif)
+	}
+
+	// The plain-text unit follows the last VIFE (EN 13757-3 6.4.2).
+	if rec.RawVIF[0]&0x7F == vifPlainText {
+		if i >= len(payload) {
+			return 0, fmt.Errorf("unexpected end of payload before plain-text VIF length")
+		}
+		n := int(payload[i])
+		i++
+		if i+n > len(payload) {
+			return 0, fmt.Errorf("plain-text VIF truncated")
+		}
+		text := make([]byte, n)
+		for k := 0; k < n; k++ {
+			text[k] = payload[i+n-1-k]
+		}
+		rec.Unit = string(text)
+		i += n
+	}
+	return i, nil
+}
+
+// dataLength returns the size of the data field selected by the DIF coding.
+func dataLength(dif byte) (int, error) {
+	switch dif & 0x0F {
+	case 0x00, 0x08:
+		return 0, nil
+	case 0x01, 0x09:
+		return 1, nil
+	case 0x02, 0x0A:
+		return 2, nil
+	case 0x03, 0x0B:
+		return 3, nil
+	case 0x04, 0x05, 0x0C:
+		return 4, nil
+	case 0x06, 0x0E:
+		return 6, nil
+	case 0x07:
+		return 8, nil
+	case dataVariable:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unsupported DIF 0x%02X", dif)
+	}
+}
+
+// LVARLength returns the size of the data following an LVAR byte (EN 13757-3
+// variable-length data, DIF coding 0x0D).
+func LVARLength(lvar byte) (int, error) {
+	switch {
+	case lvar <= 0xBF:
+		return int(lvar), nil // ASCII string
+	case lvar >= 0xC0 && lvar <= 0xC9:
+		return int(lvar - 0xC0), nil // positive BCD
+	case lvar >= 0xD0 && lvar <= 0xD9:
+		return int(lvar - 0xD0), nil // negative BCD
+	case lvar >= 0xE0 && lvar <= 0xEF:
+		return int(lvar - 0xE0), nil // binary number
+	case lvar >= 0xF0 && lvar <= 0xF4:
+		return 4 * int(lvar-0xEC), nil // binary number
+	case lvar == 0xF5:
+		return 48, nil
+	case lvar == 0xF6:
+		return 64, nil
+	default:
+		return 0, fmt.Errorf("reserved LVAR 0x%02X", lvar)
+	}
+}
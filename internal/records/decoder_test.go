@@ -0,0 +1,134 @@
+package records
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+func TestDecodeDIFEChain(t *testing.T) {
+	recs := mustDecode(t, "8C8040139999000004134400000084011322110000")
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+	if recs[0].Subunit != 2 || recs[0].Tariff != 0 || recs[0].Storage != 0 {
+		t.Fatalf("unexpected DIFE decoding %+v", recs[0])
+	}
+	if recs[2].Storage != 2 || recs[2].VIF != 0x13 {
+		t.Fatalf("unexpected storage decoding %+v", recs[2])
+	}
+}
+
+func TestDecodeExtensions(t *testing.T) {
+	recs := mustDecode(t, "0C933C01000000"+"02FD173600"+"04FB0D05000000")
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+	if recs[0].VIF != 0x13 || len(recs[0].VIFE) != 1 || recs[0].VIFE[0] != 0x3C {
+		t.Fatalf("unexpected VIFE decoding %+v", recs[0])
+	}
+	if recs[1].VIF != 0xFD17 || len(recs[1].Data) != 2 {
+		t.Fatalf("unexpected 0xFD decoding %+v", recs[1])
+	}
+	if recs[2].VIF != 0xFB0D {
+		t.Fatalf("unexpected 0xFB decoding %+v", recs[2])
+	}
+}
+
+func TestDecodePlainTextAfterVIFE(t *testing.T) {
+	// 0xFC with a forward-flow VIFE: the text length follows the VIFE.
+	recs := mustDecode(t, "02FC3B0368576B1234")
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	rec := recs[0]
+	if rec.Unit != "kWh" || len(rec.VIFE) != 1 || rec.VIFE[0] != 0x3B || len(rec.Data) != 2 {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+}
+
+func TestDecodePlainTextAndManufacturer(t *testing.T) {
+	recs := mustDecode(t, "027C0368576B1234"+"01FF0102"+"1F0102")
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+	if recs[0].Unit != "kWh" || len(recs[0].Data) != 2 {
+		t.Fatalf("unexpected plain-text unit %q", recs[0].Unit)
+	}
+	if !recs[1].IsManufacturerVIF() || len(recs[1].Data) != 1 {
+		t.Fatalf("unexpected manufacturer VIF record %+v", recs[1])
+	}
+	if !recs[2].IsManufacturerData() || !recs[2].MoreRecordsFollow() || len(recs[2].Data) != 2 {
+		t.Fatalf("unexpected manufacturer tail %+v", recs[2])
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	if _, err := Decode(mustHex(t, "0C1312")); err == nil {
+		t.Fatalf("expected error for truncated record")
+	}
+}
+
+func mustDecode(t *testing.T, s string) []Record {
+	t.Helper()
+	recs, err := Decode(mustHex(t, s))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return recs
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex decode: %v", err)
+	}
+	return b
+}
+
+func TestFieldName(t *testing.T) {
+	recs := mustDecode(t, "0C1366380000"+"8C8040139999000084011322110000"+"025B2A00"+"0C933C01000000"+"02FD173600")
+	want := []string{
+		"volume_m3",
+		"volume_subunit_2_m3",
+		"volume_storage_2_m3",
+		"flow_temperature_c",
+		"volume_backward_m3",
+		"error_flags",
+	}
+	if len(recs) != len(want) {
+		t.Fatalf("expected %d records, got %d", len(want), len(recs))
+	}
+	for i, rec := range recs {
+		if got := rec.FieldName(); got != want[i] {
+			t.Fatalf("record %d: expected %q, got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestDescribeVIF(t *testing.T) {
+	cases := []struct {
+		vif   int
+		unit  string
+		scale float64
+	}{
+		{0x06, "kWh", 1},
+		{0x13, "m3", 0.001},
+		{0x2B, "kW", 0.001},
+		{0x59, "C", 0.01},
+		{0xFB01, "kWh", 1000},
+		{0xFD47, "V", 0.01},
+		{0xFD4A, "V", 10},
+		{0xFD59, "A", 0.001},
+	}
+	for _, tc := range cases {
+		info, ok := DescribeVIF(tc.vif)
+		if !ok || info.Unit != tc.unit || math.Abs(info.Scale-tc.scale) > 1e-12 {
+			t.Fatalf("VIF 0x%02X: unexpected %+v", tc.vif, info)
+		}
+	}
+	if _, ok := DescribeVIF(0x7B); ok {
+		t.Fatalf("expected reserved VIF 0x7B to be unknown")
+	}
+}
@@ -3,13 +3,50 @@ package records
 // Record represents a decoded DIF/VIF entry from the application payload.
 type Record struct {
 	DIF  byte
-	VIF  byte
+	DIFE []byte
+	// VIF is the combined value information code: the primary VIF without
+	// its extension bit, or 0xFB00/0xFD00/0xEF00 plus the first VIFE for
+	// the extension tables. Manufacturer-specific VIFs keep 0x7F.
+	VIF int
+	// RawVIF holds the VIF and every VIFE byte as transmitted.
+	RawVIF []byte
+	// VIFE lists the combinable extensions that follow the VIF code.
 	VIFE []byte
-	Data []byte
+	// Unit carries the plain-text unit of VIF 0x7C/0xFC records.
+	Unit string
+	// LVAR is the length byte of variable-length data (DIF coding 0x0D);
+	// Data then holds the bytes it announces.
+	LVAR     byte
+	Data     []byte
+	Storage  int
+	Tariff   int
+	Subunit  int
+	Function int
 }
 
-// Decoder extracts DIF/VIF records from manufacturer payloads. Implementation
-// will follow once the parser migrates from wmbusmeters logic.
+// Decoder extracts DIF/VIF records from manufacturer payloads.
 type Decoder interface {
 	Decode(payload []byte) ([]Record, error)
 }
+
+// IsManufacturerData reports whether the record is the manufacturer-specific
+// tail introduced by DIF 0x0F or 0x1F. Data holds every remaining byte.
+func (r Record) IsManufacturerData() bool {
+	return r.DIF == difManufacturer || r.DIF == difMoreRecords
+}
+
+// MoreRecordsFollow reports DIF 0x1F: the meter continues in the next
+// telegram.
+func (r Record) MoreRecordsFollow() bool {
+	return r.DIF == difMoreRecords
+}
+
+// DataField returns the data field coding (lower DIF nibble).
+func (r Record) DataField() byte {
+	return r.DIF & 0x0F
+}
+
+// IsManufacturerVIF reports a manufacturer-specific VIF (0x7F/0xFF).
+func (r Record) IsManufacturerVIF() bool {
+	return r.VIF == vifManufacturer
+}
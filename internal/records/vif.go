@@ -0,0 +1,218 @@
+package records
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// VIFInfo describes the quantity selected by a value information code.
+type VIFInfo struct {
+	// Quantity is a snake_case name such as "energy" or "flow_temperature".
+	Quantity string
+	// Unit is the unit a scaled value is expressed in; empty for
+	// dimensionless values, dates and identifiers.
+	Unit string
+	// Scale converts the raw record value to Unit.
+	Scale float64
+}
+
+// UnitSuffix returns the unit in the lowercase form used in field names,
+// e.g. "m3h" for "m3/h" and "pct" for "%".
+func (v VIFInfo) UnitSuffix() string {
+	if v.Unit == "%" {
+		return "pct"
+	}
+	return strings.ToLower(strings.ReplaceAll(v.Unit, "/", ""))
+}
+
+var durationUnits = [4]string{"s", "min", "h", "d"}
+
+// DescribeVIF returns the EN 13757-3 meaning of a combined VIF code as stored
+// in Record.VIF. The boolean is false for reserved or unsupported codes.
+func DescribeVIF(vif int) (VIFInfo, bool) {
+	n := vif & 0x07
+	switch {
+	case vif <= 0x07:
+		return VIFInfo{"energy", "kWh", pow10(n - 6)}, true
+	case vif <= 0x0F:
+		return VIFInfo{"energy", "MJ", pow10(n - 6)}, true
+	case vif <= 0x17:
+		return VIFInfo{"volume", "m3", pow10(n - 6)}, true
+	case vif <= 0x1F:
+		return VIFInfo{"mass", "kg", pow10(n - 3)}, true
+	case vif <= 0x23:
+		return VIFInfo{"on_time", durationUnits[vif&0x03], 1}, true
+	case vif <= 0x27:
+		return VIFInfo{"operating_time", durationUnits[vif&0x03], 1}, true
+	case vif <= 0x2F:
+		return VIFInfo{"power", "kW", pow10(n - 6)}, true
+	case vif <= 0x37:
+		return VIFInfo{"power", "MJ/h", pow10(n - 6)}, true
+	case vif <= 0x3F:
+		return VIFInfo{"volume_flow", "m3/h", pow10(n - 6)}, true
+	case vif <= 0x47:
+		return VIFInfo{"volume_flow", "m3/min", pow10(n - 7)}, true
+	case vif <= 0x4F:
+		return VIFInfo{"volume_flow", "m3/s", pow10(n - 9)}, true
+	case vif <= 0x57:
+		return VIFInfo{"mass_flow", "kg/h", pow10(n - 3)}, true
+	case vif <= 0x5B:
+		return VIFInfo{"flow_temperature", "C", pow10(vif&0x03 - 3)}, true
+	case vif <= 0x5F:
+		return VIFInfo{"return_temperature", "C", pow10(vif&0x03 - 3)}, true
+	case vif <= 0x63:
+		return VIFInfo{"temperature_difference", "K", pow10(vif&0x03 - 3)}, true
+	case vif <= 0x67:
+		return VIFInfo{"external_temperature", "C", pow10(vif&0x03 - 3)}, true
+	case vif <= 0x6B:
+		return VIFInfo{"pressure", "bar", pow10(vif&0x03 - 3)}, true
+	case vif == 0x6C:
+		return VIFInfo{"date", "", 1}, true
+	case vif == 0x6D:
+		return VIFInfo{"datetime", "", 1}, true
+	case vif == 0x6E:
+		return VIFInfo{"heat_cost_allocation", "", 1}, true
+	case vif >= 0x70 && vif <= 0x73:
+		return VIFInfo{"averaging_duration", durationUnits[vif&0x03], 1}, true
+	case vif >= 0x74 && vif <= 0x77:
+		return VIFInfo{"actuality_duration", durationUnits[vif&0x03], 1}, true
+	case vif == 0x78:
+		return VIFInfo{"fabrication_number", "", 1}, true
+	case vif == 0x79:
+		return VIFInfo{"enhanced_identification", "", 1}, true
+	case vif == 0x7A:
+		return VIFInfo{"bus_address", "", 1}, true
+	case vif >= 0xFB00 && vif <= 0xFBFF:
+		return describeFB(vif & 0x7F)
+	case vif >= 0xFD00 && vif <= 0xFDFF:
+		return describeFD(vif & 0x7F)
+	default:
+		return VIFInfo{}, false
+	}
+}
+
+// describeFB covers the first extension table (VIF 0xFB).
+func describeFB(code int) (VIFInfo, bool) {
+	switch {
+	case code <= 0x01:
+		return VIFInfo{"energy", "kWh", pow10(code&0x01 + 2)}, true
+	case code >= 0x08 && code <= 0x09:
+		return VIFInfo{"energy", "MJ", pow10(code&0x01 + 2)}, true
+	case code >= 0x10 && code <= 0x11:
+		return VIFInfo{"volume", "m3", pow10(code&0x01 + 2)}, true
+	case code >= 0x18 && code <= 0x19:
+		return VIFInfo{"mass", "kg", pow10(code&0x01 + 5)}, true
+	case code >= 0x1A && code <= 0x1B:
+		return VIFInfo{"relative_humidity", "%", pow10(code&0x01 - 1)}, true
+	case code >= 0x28 && code <= 0x29:
+		return VIFInfo{"power", "kW", pow10(code&0x01 + 2)}, true
+	case code >= 0x30 && code <= 0x31:
+		return VIFInfo{"power", "MJ/h", pow10(code&0x01 + 2)}, true
+	default:
+		return VIFInfo{}, false
+	}
+}
+
+// describeFD covers the second extension table (VIF 0xFD).
+func describeFD(code int) (VIFInfo, bool) {
+	switch {
+	case code == 0x08:
+		return VIFInfo{"access_number", "", 1}, true
+	case code == 0x09:
+		return VIFInfo{"medium", "", 1}, true
+	case code == 0x0A:
+		return VIFInfo{"manufacturer", "", 1}, true
+	case code == 0x0B:
+		return VIFInfo{"parameter_set", "", 1}, true
+	case code == 0x0C:
+		return VIFInfo{"model_version", "", 1}, true
+	case code == 0x0D:
+		return VIFInfo{"hardware_version", "", 1}, true
+	case code == 0x0E:
+		return VIFInfo{"firmware_version", "", 1}, true
+	case code == 0x0F:
+		return VIFInfo{"software_version", "", 1}, true
+	case code == 0x10:
+		return VIFInfo{"customer_location", "", 1}, true
+	case code == 0x11:
+		return VIFInfo{"customer", "", 1}, true
+	case code == 0x16:
+		return VIFInfo{"password", "", 1}, true
+	case code == 0x17:
+		return VIFInfo{"error_flags", "", 1}, true
+	case code == 0x1A:
+		return VIFInfo{"digital_output", "", 1}, true
+	case code == 0x1B:
+		return VIFInfo{"digital_input", "", 1}, true
+	case code == 0x1C:
+		return VIFInfo{"baud_rate", "", 1}, true
+	case code == 0x3A:
+		return VIFInfo{"dimensionless", "", 1}, true
+	case code >= 0x40 && code <= 0x4F:
+		return VIFInfo{"voltage", "V", pow10(code&0x0F - 9)}, true
+	case code >= 0x50 && code <= 0x5F:
+		return VIFInfo{"current", "A", pow10(code&0x0F - 12)}, true
+	case code == 0x60:
+		return VIFInfo{"reset_counter", "", 1}, true
+	case code == 0x61:
+		return VIFInfo{"cumulation_counter", "", 1}, true
+	case code == 0x74:
+		return VIFInfo{"remaining_battery", "d", 1}, true
+	default:
+		return VIFInfo{}, false
+	}
+}
+
+// combinableNames covers the VIFE codes that qualify the primary quantity
+// (EN 13757-3 table 15) and are common enough to spell out.
+var combinableNames = map[byte]string{
+	0x39: "start_of",
+	0x3A: "uncorrected",
+	0x3B: "forward",
+	0x3C: "backward",
+}
+
+var functionNames = [4]string{"", "max_", "min_", "error_"}
+
+// FieldName builds a descriptive key for the record from its VIF, function,
+// tariff, subunit and storage number, followed by the unit, e.g.
+// "volume_tariff_1_storage_2_m3". Codes without a known meaning are named by
+// their hex value.
+func (r Record) FieldName() string {
+	var b strings.Builder
+	b.WriteString(functionNames[r.Function&0x03])
+	info, ok := DescribeVIF(r.VIF)
+	switch {
+	case ok:
+		b.WriteString(info.Quantity)
+	case r.IsManufacturerVIF():
+		b.WriteString("manufacturer_specific")
+	case r.VIF&0x7F == vifPlainText:
+		b.WriteString(strings.ToLower(r.Unit))
+	default:
+		fmt.Fprintf(&b, "vif_%02X", r.VIF)
+	}
+	for _, vife := range r.VIFE {
+		if name, known := combinableNames[vife&0x7F]; known {
+			b.WriteString("_" + name)
+		} else {
+			fmt.Fprintf(&b, "_vife_%02X", vife&0x7F)
+		}
+	}
+	if r.Tariff != 0 {
+		fmt.Fprintf(&b, "_tariff_%d", r.Tariff)
+	}
+	if r.Subunit != 0 {
+		fmt.Fprintf(&b, "_subunit_%d", r.Subunit)
+	}
+	if r.Storage != 0 {
+		fmt.Fprintf(&b, "_storage_%d", r.Storage)
+	}
+	if suffix := info.UnitSuffix(); suffix != "" {
+		b.WriteString("_" + suffix)
+	}
+	return b.String()
+}
+
+func pow10(n int) float64 { return math.Pow10(n) }
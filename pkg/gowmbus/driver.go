@@ -0,0 +1,177 @@
+package gowmbus
+
+import (
+	"github.com/d21d3q/gowmbus/internal/crypto"
+	"github.com/d21d3q/gowmbus/internal/driver"
+	"github.com/d21d3q/gowmbus/internal/driver/declarative"
+	"github.com/d21d3q/gowmbus/internal/driver/wmbus"
+	"github.com/d21d3q/gowmbus/internal/frame"
+	"github.com/d21d3q/gowmbus/internal/records"
+)
+
+// Telegram is a parsed link and transport layer together with the
+// application payload handed to drivers.
+type Telegram = frame.Telegram
+
+// Address identifies a meter by manufacturer, ID, version and device type.
+type Address = frame.Address
+
+// Driver decodes the payload of the telegrams it was selected for. Process
+// receives the telegram after decryption; the context carries the options
+// passed to AnalyzeHexWithOptions.
+type Driver = driver.Driver
+
+// PartialReporter is implemented by drivers that can report basic fields
+// when decryption or decoding fails.
+type PartialReporter = driver.PartialReporter
+
+// HistoryReporter is implemented by drivers that report historic storage
+// slots separately from the current values.
+type HistoryReporter = driver.HistoryReporter
+
+// MeasurementReporter is implemented by drivers that describe their numeric
+// fields as measurements with explicit units.
+type MeasurementReporter = driver.MeasurementReporter
+
+// Reporter is implemented by drivers that decode their fields, history and
+// measurements in one pass. It takes the place of Process, HistoryReporter
+// and MeasurementReporter.
+type Reporter = driver.Reporter
+
+// Report is the outcome of Reporter: the fields, the historic slots and the
+// measurements of one telegram.
+type Report = driver.Report
+
+// Measurement is a numeric reading with its quantity, unit, storage, tariff,
+// subunit and originating record.
+type Measurement = driver.Measurement
+
+// History holds the values of one historic storage slot and the date of
+// its own date record.
+type History = driver.History
+
+// Detection selects the telegrams a driver handles.
+type Detection = driver.Detection
+
+// VersionRange is an inclusive range of meter versions used by Detection.
+type VersionRange = driver.VersionRange
+
+// DriverInfo describes a registered driver and its detection rule.
+type DriverInfo = driver.Registration
+
+// FrameKind is the link-layer function announced by the C field.
+type FrameKind = frame.FrameKind
+
+// Frame kinds decoded from the C field.
+const (
+	FrameUnknown = frame.FrameUnknown
+	FrameSndNke  = frame.FrameSndNke
+	FrameSndUD   = frame.FrameSndUD
+	FrameSndNR   = frame.FrameSndNR
+	FrameSndIR   = frame.FrameSndIR
+	FrameAccNR   = frame.FrameAccNR
+	FrameAccDmd  = frame.FrameAccDmd
+	FrameReqUD1  = frame.FrameReqUD1
+	FrameReqUD2  = frame.FrameReqUD2
+	FrameAck     = frame.FrameAck
+	FrameNack    = frame.FrameNack
+	FrameCnfIR   = frame.FrameCnfIR
+	FrameRspUD   = frame.FrameRspUD
+)
+
+// ApplicationError is the content of an application error telegram.
+type ApplicationError = frame.ApplicationError
+
+// Alarm is the content of an alarm telegram.
+type Alarm = frame.Alarm
+
+// Record is a decoded EN 13757-3 data record.
+type Record = records.Record
+
+// Value is a record data field decoded according to its DIF.
+type Value = wmbus.Value
+
+// Errors reported while decrypting telegrams.
+var (
+	ErrKeyRequired     = crypto.ErrKeyRequired
+	ErrInvalidKey      = crypto.ErrInvalidKey
+	ErrAuthentication  = crypto.ErrAuthentication
+	ErrUnsupportedMode = crypto.ErrUnsupportedMode
+)
+
+// ManufacturerID packs a three-letter FLAG code such as "KAM" into the
+// manufacturer field used by Detection.
+func ManufacturerID(code string) (uint16, error) {
+	return frame.ManufacturerID(code)
+}
+
+// DeviceTypeName returns the medium announced by a device type byte.
+func DeviceTypeName(deviceType byte) string {
+	return frame.DeviceTypeName(deviceType)
+}
+
+// CIName describes a CI field, e.g. "RSP_UD (short TPL)".
+func CIName(ci byte) string {
+	return frame.CIName(ci)
+}
+
+// RegisterDriver adds a driver for telegrams matching det. It is safe to call
+// from init functions of packages outside this module.
+func RegisterDriver(det Detection, drv Driver) {
+	driver.Register(det, drv)
+}
+
+// RegisterDriverPriority adds a driver whose detection wins over matching
+// drivers with a lower priority. Built-in drivers use priority 0.
+func RegisterDriverPriority(det Detection, drv Driver, priority int) {
+	driver.RegisterPriority(det, drv, priority)
+}
+
+// UnregisterDriver removes every detection registered for drv, e.g. in a
+// test cleanup. drv must be the comparable value that was registered.
+func UnregisterDriver(drv Driver) {
+	driver.Unregister(drv)
+}
+
+// LoadDriverFiles registers the declarative drivers defined in the given JSON
+// or YAML files or directories for every later call in the process. Files
+// that were already registered are skipped unless they changed since, in
+// which case the new definition replaces the old one.
+// AnalyzeOptions.DriverFiles scopes drivers to a single call instead.
+func LoadDriverFiles(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return declarative.RegisterFiles(paths...)
+}
+
+// Drivers lists the registered drivers in lookup order.
+func Drivers() []DriverInfo {
+	return driver.Registrations()
+}
+
+// ParseFrame parses a wired long frame or a wireless telegram, stripping
+// link-layer CRCs as selected. The payload may still be encrypted.
+func ParseFrame(data []byte, crc LinkCRC) (Telegram, error) {
+	return parseFrame(data, crc)
+}
+
+// Decrypt removes Extended Link Layer and transport layer encryption in
+// place. A nil key is accepted for plaintext telegrams.
+func Decrypt(t *Telegram, key []byte) error {
+	if err := crypto.DecryptELL(t, key); err != nil {
+		return err
+	}
+	return crypto.Decrypt(t, key)
+}
+
+// DecodeRecords splits an application payload into data records. A
+// manufacturer-specific tail is returned as the final record.
+func DecodeRecords(payload []byte) ([]Record, error) {
+	return records.Decode(payload)
+}
+
+// DecodeRecordValue decodes the data field of a record.
+func DecodeRecordValue(rec Record) (Value, error) {
+	return wmbus.RecordValue(rec)
+}
@@ -0,0 +1,72 @@
+package gowmbus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/d21d3q/gowmbus/pkg/gowmbus"
+)
+
+type inHouseDriver struct{}
+
+func (inHouseDriver) Name() string { return "inhouse" }
+
+func (inHouseDriver) Process(_ context.Context, t *gowmbus.Telegram) (map[string]any, error) {
+	recs, err := gowmbus.DecodeRecords(t.Payload)
+	if err != nil {
+		return nil, err
+	}
+	value, err := gowmbus.DecodeRecordValue(recs[0])
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"id": t.MeterIDString(), "counter": value.Float()}, nil
+}
+
+func TestRegisterDriver(t *testing.T) {
+	gowmbus.RegisterDriver(gowmbus.Detection{
+		Manufacturer: 0x1234,
+		CI:           0x7A,
+		Versions:     []gowmbus.VersionRange{{Min: 0x01, Max: 0x02}},
+	}, inHouseDriver{})
+	t.Cleanup(func() { gowmbus.UnregisterDriver(inHouseDriver{}) })
+
+	var found bool
+	for _, info := range gowmbus.Drivers() {
+		if info.Name == "inhouse" {
+			found = true
+			require.Equal(t, uint16(0x1234), info.Detection.Manufacturer)
+		}
+	}
+	require.True(t, found, "registered driver not listed")
+
+	ctx := context.Background()
+	result, err := gowmbus.AnalyzeHex(ctx, "1344341278563412010E7A2A0000000B2D040000")
+	require.NoError(t, err)
+	require.Equal(t, "inhouse", result.Driver)
+	require.Equal(t, "12345678", result.Fields["id"])
+	require.InDelta(t, 4.0, result.Fields["counter"], 1e-9)
+}
+
+func TestUnregisterDriver(t *testing.T) {
+	gowmbus.RegisterDriver(gowmbus.Detection{Manufacturer: 0x1234, CI: 0x7A}, inHouseDriver{})
+	gowmbus.UnregisterDriver(inHouseDriver{})
+	for _, info := range gowmbus.Drivers() {
+		require.NotEqual(t, "inhouse", info.Name)
+	}
+}
+
+func TestManufacturerID(t *testing.T) {
+	id, err := gowmbus.ManufacturerID("DMD")
+	require.NoError(t, err)
+	require.Equal(t, uint16(0x11A4), id)
+
+	id, err = gowmbus.ManufacturerID("BMT")
+	require.NoError(t, err)
+	require.Equal(t, uint16(0x09B4), id)
+
+	_, err = gowmbus.ManufacturerID("TOOLONG")
+	require.Error(t, err)
+}
@@ -4,16 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+
+	"github.com/d21d3q/gowmbus/pkg/units"
 )
 
 // FieldSet offers typed helpers on top of a dynamic field map.
 type FieldSet struct {
-	data map[string]any
+	data         map[string]any
+	measurements []Measurement
 }
 
-// FieldSet returns a FieldSet wrapper for the result's fields.
+// FieldSet returns a FieldSet wrapper for the result's fields and
+// measurements.
 func (r Result) FieldSet() FieldSet {
-	return FieldSet{data: r.Fields}
+	return FieldSet{data: r.Fields, measurements: r.Measurements}
 }
 
 // Map exposes the underlying map for callers that still need raw access.
@@ -145,3 +149,60 @@ func (fs FieldSet) Bool(key string) (bool, error) {
 		return false, fmt.Errorf("field %q has unsupported type %T", key, v)
 	}
 }
+
+// Measurement returns the current (storage 0) measurement reported under
+// key.
+func (fs FieldSet) Measurement(key string) (Measurement, bool) {
+	for _, m := range fs.measurements {
+		if m.Name == key && m.Storage == 0 {
+			return m, true
+		}
+	}
+	return Measurement{}, false
+}
+
+// Unit returns the unit of the field, e.g. "kWh" for total_heating_kwh.
+func (fs FieldSet) Unit(key string) (string, error) {
+	m, ok := fs.Measurement(key)
+	if !ok {
+		return "", fmt.Errorf("field %q has no unit", key)
+	}
+	return m.Unit, nil
+}
+
+// FloatWithUnit returns the field's value together with its unit.
+func (fs FieldSet) FloatWithUnit(key string) (float64, string, error) {
+	m, ok := fs.Measurement(key)
+	if !ok {
+		return 0, "", fmt.Errorf("field %q has no unit", key)
+	}
+	return m.Value, m.Unit, nil
+}
+
+// Quantity returns the field converted to targetUnit, e.g. "GJ" for an
+// energy field the driver reports in kWh. Errors wrap units.ErrIncompatible
+// when the field measures a different dimension and units.ErrUnknownUnit
+// for unknown symbols.
+func (fs FieldSet) Quantity(key, targetUnit string) (float64, error) {
+	m, ok := fs.Measurement(key)
+	if !ok {
+		return 0, fmt.Errorf("field %q has no unit", key)
+	}
+	value, err := units.ConvertQuantity(m.Value, m.Quantity, m.Unit, targetUnit)
+	if err != nil {
+		return 0, fmt.Errorf("field %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// Measurements returns every measurement of the given quantity, current and
+// historic, e.g. all energy readings.
+func (fs FieldSet) Measurements(quantity string) []Measurement {
+	var out []Measurement
+	for _, m := range fs.measurements {
+		if m.Quantity == quantity {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -7,13 +7,17 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/d21d3q/gowmbus/internal/crypto"
 	"github.com/d21d3q/gowmbus/internal/driver"
+	_ "github.com/d21d3q/gowmbus/internal/driver/auto" // register fallback driver
+	"github.com/d21d3q/gowmbus/internal/driver/declarative"
 	_ "github.com/d21d3q/gowmbus/internal/driver/hydrocalm4" // register driver
 	_ "github.com/d21d3q/gowmbus/internal/driver/hydrodigit" // register driver
 	"github.com/d21d3q/gowmbus/internal/frame"
+	internalopts "github.com/d21d3q/gowmbus/internal/options"
 )
 
 // Result captures the outcome of AnalyzeHex.
@@ -21,8 +25,40 @@ type Result struct {
 	Driver    string
 	RawHex    string
 	ByteCount int
-	Telegram  *frame.Telegram
-	Fields    map[string]any
+	// FrameKind is the link-layer function decoded from the C field.
+	FrameKind FrameKind
+	// Manufacturer is the three-letter FLAG code of the meter, e.g. "BMT";
+	// ManufacturerName is the vendor name when the code is known.
+	Manufacturer     string
+	ManufacturerName string
+	Telegram         *frame.Telegram
+	// ReceivedAt is the receive time taken from AnalyzeOptions; Timestamp
+	// is the same time in RFC 3339 (UTC), or the wmbusmeters placeholder in
+	// compatibility mode, matching the drivers' "timestamp" field.
+	ReceivedAt time.Time
+	Timestamp  string
+	// ApplicationError and Alarm are set for application error (CI
+	// 0x6E-0x70) and alarm (CI 0x71, 0x74, 0x75) telegrams, which are
+	// decoded without a driver.
+	ApplicationError *ApplicationError
+	Alarm            *Alarm
+	Fields           map[string]any
+	// History lists the historic storage slots (storage number 1 and up),
+	// such as billing-date readings, apart from the current values in
+	// Fields. It is empty unless the driver implements Reporter or
+	// HistoryReporter.
+	History []History
+	// HistoryErr reports the historic slots the driver failed to decode;
+	// they are missing from History while the others are kept.
+	HistoryErr error
+	// Measurements describes the numeric fields, current and historic, with
+	// their quantity and unit. Fields keeps the legacy unit-suffixed keys;
+	// each measurement's Name is its key there. It is empty unless the
+	// driver implements Reporter or MeasurementReporter.
+	Measurements []Measurement
+	// MeasurementsErr reports the measurements the driver failed to decode.
+	// For a Reporter these are the ones of the slots in HistoryErr.
+	MeasurementsErr error
 }
 
 // String renders a human-readable representation of the result.
@@ -31,15 +67,40 @@ func (r Result) String() string {
 		"driver":     r.Driver,
 		"byte_count": r.ByteCount,
 		"raw_hex":    r.RawHex,
+		"timestamp":  r.Timestamp,
 	}
 	if r.Telegram != nil {
+		summary["frame_kind"] = r.FrameKind.String()
 		summary["meter_id"] = r.Telegram.MeterIDString()
-		summary["manufacturer"] = fmt.Sprintf("0x%04X", r.Telegram.Manufacturer)
+		summary["manufacturer"] = r.Manufacturer
+		summary["manufacturer_id"] = fmt.Sprintf("0x%04X", r.Telegram.Identity().Manufacturer)
+		if r.ManufacturerName != "" {
+			summary["manufacturer_name"] = r.ManufacturerName
+		}
+		summary["device_type"] = r.Telegram.Media()
 		summary["ci"] = fmt.Sprintf("0x%02X", r.Telegram.CI)
+		summary["ci_name"] = frame.CIName(r.Telegram.CI)
 	}
 	if len(r.Fields) > 0 {
 		summary["fields"] = r.Fields
 	}
+	if len(r.History) > 0 {
+		history := make([]map[string]any, 0, len(r.History))
+		for _, h := range r.History {
+			entry := map[string]any{"storage": h.Storage, "fields": h.Fields}
+			if !h.Date.IsZero() {
+				entry["date"] = h.Date.Format("2006-01-02 15:04")
+			}
+			history = append(history, entry)
+		}
+		summary["history"] = history
+	}
+	if r.HistoryErr != nil {
+		summary["history_error"] = r.HistoryErr.Error()
+	}
+	if r.MeasurementsErr != nil {
+		summary["measurements_error"] = r.MeasurementsErr.Error()
+	}
 	data, err := json.MarshalIndent(summary, "", "  ")
 	if err != nil {
 		return fmt.Sprintf("driver: %s bytes:%d raw:%s (marshal error: %v)", r.Driver, r.ByteCount, r.RawHex, err)
@@ -48,6 +109,7 @@ func (r Result) String() string {
 }
 
 // AnalyzeHex parses the frame, selects a driver, and returns decoded data.
+// Both wireless telegrams and wired M-Bus long frames are accepted.
 func AnalyzeHex(ctx context.Context, raw string) (Result, error) {
 	return AnalyzeHexWithOptions(ctx, raw, AnalyzeOptions{})
 }
@@ -58,11 +120,15 @@ func AnalyzeHexWithOptions(ctx context.Context, raw string, opts AnalyzeOptions)
 	if err != nil {
 		return Result{}, err
 	}
+	local, err := loadLocalDrivers(opts.DriverFiles)
+	if err != nil {
+		return Result{}, err
+	}
 	data, err := decodeHex(raw)
 	if err != nil {
 		return Result{}, err
 	}
-	telegram, err := frame.Parse(data)
+	telegram, err := parseFrame(data, opts.LinkCRC)
 	if err != nil {
 		return Result{}, err
 	}
@@ -71,42 +137,202 @@ func AnalyzeHexWithOptions(ctx context.Context, raw string, opts AnalyzeOptions)
 		Driver:    "unknown",
 		RawHex:    strings.ToUpper(stripWhitespace(raw)),
 		ByteCount: len(data),
+		FrameKind: telegram.Kind(),
 		Telegram:  &telegram,
+		Timestamp: internalopts.Timestamp(ctxWithKey),
 	}
+	result.ReceivedAt, _ = internalopts.ReceiveTime(ctxWithKey)
+	result.setManufacturer()
 
-	drv, err := driver.Lookup(&telegram)
+	// The ELL encrypts the transport layer as well, so it has to be removed
+	// before the chained CI is available for driver detection.
+	if err := crypto.DecryptELL(&telegram, key); err != nil {
+		if errors.Is(err, crypto.ErrKeyRequired) {
+			// Only the link layer identity is readable; drivers that can
+			// report it still get the chance to do so.
+			drv, selErr := selectDriver(&telegram, opts.Driver, local)
+			if selErr != nil && opts.Driver != "" {
+				return result, selErr
+			}
+			if selErr == nil {
+				if partial, ok := encryptedResult(result, drv, &telegram, err); ok {
+					return partial, nil
+				}
+			}
+		}
+		return result, err
+	}
+
+	// A long transport header inside the ELL may carry the meter identity.
+	result.setManufacturer()
+
+	if telegram.Kind().CarriesData() && (telegram.IsApplicationError() || telegram.IsAlarm()) {
+		return analyzeStatusTelegram(result, &telegram, key)
+	}
+
+	// Installation requests without data, access demands and acknowledgements
+	// are reported as such; there is nothing for a driver to decode.
+	if !telegram.HasApplicationData() {
+		result.Driver = "none"
+		return result, nil
+	}
+
+	drv, err := selectDriver(&telegram, opts.Driver, local)
 	if err != nil {
+		if opts.Driver != "" {
+			return result, err
+		}
 		return result, nil
 	}
+	driver.ApplyStatusNames(drv, &telegram)
 	if err := crypto.Decrypt(&telegram, key); err != nil {
 		if errors.Is(err, crypto.ErrKeyRequired) {
-			if reporter, ok := drv.(driver.PartialReporter); ok {
-				fields := reporter.PartialFields(&telegram)
-				fields["encryption"] = err.Error()
-				result.Driver = drv.Name()
-				result.Fields = fields
-				return result, nil
+			if partial, ok := encryptedResult(result, drv, &telegram, err); ok {
+				return partial, nil
 			}
 		}
 		return result, err
 	}
 
+	if reporter, ok := drv.(driver.Reporter); ok {
+		report, err := reporter.Report(ctxWithKey, &telegram)
+		if err != nil {
+			return failedResult(result, drv, &telegram, err)
+		}
+		result.Driver = drv.Name()
+		result.Fields = report.Fields
+		result.History, result.HistoryErr = report.History, report.HistoryErr
+		// A failed historic slot is missing from the measurements as well.
+		result.Measurements, result.MeasurementsErr = report.Measurements, report.HistoryErr
+		return result, nil
+	}
+
 	fields, err := drv.Process(ctxWithKey, &telegram)
 	if err != nil {
-		if reporter, ok := drv.(driver.PartialReporter); ok {
-			partial := reporter.PartialFields(&telegram)
-			partial["error"] = err.Error()
-			result.Driver = drv.Name()
-			result.Fields = partial
-			return result, nil
-		}
+		return failedResult(result, drv, &telegram, err)
+	}
+	result.Driver = drv.Name()
+	result.Fields = fields
+	// The current values stand on their own; a bad historic record is
+	// reported next to them instead of failing the telegram.
+	if reporter, ok := drv.(driver.HistoryReporter); ok {
+		result.History, result.HistoryErr = reporter.History(ctxWithKey, &telegram)
+	}
+	if reporter, ok := drv.(driver.MeasurementReporter); ok {
+		result.Measurements, result.MeasurementsErr = reporter.Measurements(ctxWithKey, &telegram)
+	}
+	return result, nil
+}
+
+// failedResult reports the partial fields of drv with the decoding error,
+// or returns the error when drv has no partial fields to offer.
+func failedResult(result Result, drv driver.Driver, t *frame.Telegram, err error) (Result, error) {
+	reporter, ok := drv.(driver.PartialReporter)
+	if !ok {
 		return result, err
 	}
+	partial := reporter.PartialFields(t)
+	partial["error"] = err.Error()
 	result.Driver = drv.Name()
+	result.Fields = partial
+	return result, nil
+}
+
+// encryptedResult reports the partial fields of drv with the encryption
+// error when a telegram cannot be decrypted for lack of a key. It returns
+// false when drv has no partial fields to offer.
+func encryptedResult(result Result, drv driver.Driver, t *frame.Telegram, err error) (Result, bool) {
+	reporter, ok := drv.(driver.PartialReporter)
+	if !ok {
+		return result, false
+	}
+	fields := reporter.PartialFields(t)
+	fields["encryption"] = err.Error()
+	result.Driver = drv.Name()
+	result.Fields = fields
+	return result, true
+}
+
+// analyzeStatusTelegram reports application error and alarm telegrams as
+// structured fields instead of passing them to a driver.
+func analyzeStatusTelegram(result Result, t *frame.Telegram, key []byte) (Result, error) {
+	result.Driver = "none"
+	fields := map[string]any{
+		"_":     "telegram",
+		"id":    t.MeterIDString(),
+		"media": t.Media(),
+	}
 	result.Fields = fields
+	if err := crypto.Decrypt(t, key); err != nil {
+		if errors.Is(err, crypto.ErrKeyRequired) {
+			fields["encryption"] = err.Error()
+			return result, nil
+		}
+		return result, err
+	}
+	if appErr, ok := t.ApplicationError(); ok {
+		result.ApplicationError = &appErr
+		fields["application_error"] = appErr.Description()
+		fields["application_error_code"] = float64(appErr.Code)
+		if len(appErr.Data) > 0 {
+			fields["application_error_data"] = fmt.Sprintf("%X", appErr.Data)
+		}
+	}
+	if alarm, ok := t.Alarm(); ok {
+		result.Alarm = &alarm
+		fields["alarm"] = alarm.Active()
+		fields["alarm_status_hex"] = fmt.Sprintf("%X", alarm.Status)
+		bits := make([]any, 0, len(alarm.Status)*8)
+		for _, bit := range alarm.Bits() {
+			bits = append(bits, float64(bit))
+		}
+		fields["alarm_bits"] = bits
+	}
 	return result, nil
 }
 
+func (r *Result) setManufacturer() {
+	id := r.Telegram.Identity()
+	r.Manufacturer = id.ManufacturerCode()
+	r.ManufacturerName = id.ManufacturerName()
+}
+
+// selectDriver returns the driver forced by name, or the best match for the
+// telegram when name is empty. The local candidates are considered next to
+// the registered drivers for this call only.
+func selectDriver(t *frame.Telegram, name string, local []driver.Candidate) (driver.Driver, error) {
+	if name != "" {
+		return driver.ByNameWith(local, name)
+	}
+	return driver.LookupWith(local, t)
+}
+
+// loadLocalDrivers parses the per-call declarative driver files without
+// registering them.
+func loadLocalDrivers(paths []string) ([]driver.Candidate, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	drivers, err := declarative.LoadFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return declarative.Candidates(drivers), nil
+}
+
+// parseFrame accepts wired M-Bus frames as well as wireless telegrams,
+// removing the link-layer CRCs of the latter as requested.
+func parseFrame(data []byte, crc LinkCRC) (frame.Telegram, error) {
+	if frame.IsWiredFrame(data) {
+		return frame.ParseWired(data)
+	}
+	stripped, err := crc.strip(data)
+	if err != nil {
+		return frame.Telegram{}, err
+	}
+	return frame.Parse(stripped)
+}
+
 func decodeHex(input string) ([]byte, error) {
 	clean := stripWhitespace(input)
 	if strings.HasPrefix(clean, "0X") {
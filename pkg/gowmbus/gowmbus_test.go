@@ -2,9 +2,16 @@ package gowmbus
 
 import (
 	"context"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/d21d3q/gowmbus/internal/crypto"
+	"github.com/d21d3q/gowmbus/internal/records"
+	"github.com/d21d3q/gowmbus/pkg/units"
 )
 
 func TestDecodeHex(t *testing.T) {
@@ -27,4 +34,360 @@ func TestAnalyzeHexHydrodigit(t *testing.T) {
 	require.Equal(t, "hydrodigit", result.Driver)
 	require.NotNil(t, result.Telegram)
 	require.Equal(t, "86868686", result.Telegram.MeterIDString())
+	require.Equal(t, "BMT", result.Manufacturer)
+	require.Equal(t, "BMETERS", result.ManufacturerName)
+	require.Contains(t, result.String(), `"manufacturer": "BMT"`)
+}
+
+func TestAnalyzeHexLongHeader(t *testing.T) {
+	ctx := context.Background()
+	frame := "4E442D2C4433221101317286868686B4091307F00000000C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B3000000"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.Equal(t, "86868686", result.Fields["id"])
+	require.InDelta(t, 3.866, result.Fields["total_m3"], 1e-6)
+}
+
+func TestAnalyzeHexELLEncrypted(t *testing.T) {
+	ctx := context.Background()
+	frame := "4F44B4098686868613078D20493412002095DD2C836BFCF69E269DDE92D7246BCA7FDA8814A2565A3596304FD392F5AD6C3EF09B0937B7470A3305EB2F87D217646C3A17FA88788BDB9F6A505AC72C43"
+	result, err := AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{KeyHex: strings.Repeat("11", 16)})
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.InDelta(t, 3.866, result.Fields["total_m3"], 1e-6)
+
+	_, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{KeyHex: strings.Repeat("22", 16)})
+	require.ErrorIs(t, err, crypto.ErrInvalidKey)
+}
+
+func TestAnalyzeHexELLEncryptedWithoutKey(t *testing.T) {
+	ctx := context.Background()
+	frame := "4F44B4098686868613078D20493412002095DD2C836BFCF69E269DDE92D7246BCA7FDA8814A2565A3596304FD392F5AD6C3EF09B0937B7470A3305EB2F87D217646C3A17FA88788BDB9F6A505AC72C43"
+	// The chained CI is encrypted, so only the fallback driver matches.
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "auto", result.Driver)
+	require.Equal(t, "86868686", result.Fields["id"])
+	require.Equal(t, crypto.ErrKeyRequired.Error(), result.Fields["encryption"])
+
+	result, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{Driver: "hydrodigit"})
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.Equal(t, "86868686", result.Fields["id"])
+	require.Equal(t, crypto.ErrKeyRequired.Error(), result.Fields["encryption"])
+	require.NotContains(t, result.Fields, "total_m3")
+}
+
+func TestAnalyzeHexAFL(t *testing.T) {
+	ctx := context.Background()
+	frame := "5244B409868686861307900200007AF00040052F2F0C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000002F2F2F2F2F2F"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.InDelta(t, 3.866, result.Fields["total_m3"], 1e-6)
+}
+
+func TestAnalyzeHexWired(t *testing.T) {
+	ctx := context.Background()
+	frame := "6847476808057286868686B4091307F00000000C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000004316"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.Equal(t, "86868686", result.Fields["id"])
+	require.InDelta(t, 3.866, result.Fields["total_m3"], 1e-6)
+}
+
+func TestAnalyzeHexLinkCRC(t *testing.T) {
+	ctx := context.Background()
+	frame := "2C44B409381317051A0DA02A8C00497A76000000046D25AA153A0C036E4C000000000C13000000000F64000000001D4E000000FFFF"
+	result, err := AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{LinkCRC: LinkCRCAuto})
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4", result.Driver)
+	require.Equal(t, "2024-10-21 10:37", result.Fields["device_datetime"])
+
+	corrupt := strings.Replace(frame, "0C036E4C", "0C036E4D", 1)
+	_, err = AnalyzeHexWithOptions(ctx, corrupt, AnalyzeOptions{LinkCRC: LinkCRCFormatA})
+	var crcErr *CRCError
+	require.ErrorAs(t, err, &crcErr)
+}
+
+func TestAnalyzeHexAutoDriver(t *testing.T) {
+	ctx := context.Background()
+	frame := "2644 2D2C 78563412 1B16 7A 2A000000 0C1366380000 046D27287E2A 025B2A00 02FD173600 0F0102"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "auto", result.Driver)
+	require.Equal(t, "12345678", result.Fields["id"])
+	require.Equal(t, "KAM", result.Manufacturer)
+	require.InDelta(t, 3.866, result.Fields["volume_m3"], 1e-6)
+	require.Equal(t, "2019-10-30 08:39", result.Fields["datetime"])
+	require.InDelta(t, 42.0, result.Fields["flow_temperature_c"], 1e-6)
+	require.InDelta(t, 54.0, result.Fields["error_flags"], 1e-6)
+	require.Equal(t, "0102", result.Fields["manufacturer_data"])
+}
+
+func TestAnalyzeHexForcedDriver(t *testing.T) {
+	ctx := context.Background()
+	frame := "4E44B4098686868613077AF00040052F2F0C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000002F2F2F2F2F2F"
+	result, err := AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{Driver: "auto"})
+	require.NoError(t, err)
+	require.Equal(t, "auto", result.Driver)
+	require.InDelta(t, 3.866, result.Fields["volume_m3"], 1e-6)
+
+	_, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{Driver: "missing"})
+	require.Error(t, err)
+}
+
+func TestAnalyzeHexDriverFiles(t *testing.T) {
+	ctx := context.Background()
+	frame := "3E44B409381317051A0D8C00497A7C000000046D2BAA153A0C03000000000C13000000000B3B0000000B280000000A5930230A5D08250F6402000000000000"
+	opts := AnalyzeOptions{Driver: "hydrocalm4-declarative", DriverFiles: []string{"../../testdata/drivers"}}
+	result, err := AnalyzeHexWithOptions(ctx, frame, opts)
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4-declarative", result.Driver)
+	require.InDelta(t, 23.3, result.Fields["supply_temperature_c"], 1e-9)
+	require.InDelta(t, 25.08, result.Fields["return_temperature_c"], 1e-9)
+	require.Equal(t, "2024-10-21 10:43", result.Fields["device_datetime"])
+
+	_, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{DriverFiles: []string{"missing.yaml"}})
+	require.Error(t, err)
+
+	// Per-call driver files do not leak into later calls.
+	_, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{Driver: "hydrocalm4-declarative"})
+	require.Error(t, err)
+}
+
+func TestAnalyzeHexFrameKind(t *testing.T) {
+	ctx := context.Background()
+	result, err := AnalyzeHex(ctx, "0948B409868686861307")
+	require.NoError(t, err)
+	require.Equal(t, FrameAccDmd, result.FrameKind)
+	require.Equal(t, "none", result.Driver)
+	require.Nil(t, result.Fields)
+
+	installation := "4E46B4098686868613077AF00040052F2F0C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000002F2F2F2F2F2F"
+	result, err = AnalyzeHex(ctx, installation)
+	require.NoError(t, err)
+	require.Equal(t, FrameSndIR, result.FrameKind)
+	require.Equal(t, "hydrodigit", result.Driver)
+
+	result, err = AnalyzeHex(ctx, "107BFE7916")
+	require.NoError(t, err)
+	require.Equal(t, FrameReqUD2, result.FrameKind)
+	require.Equal(t, "none", result.Driver)
+}
+
+func TestAnalyzeHexStatusTelegrams(t *testing.T) {
+	ctx := context.Background()
+	result, err := AnalyzeHex(ctx, "0B44B4098686868613077008")
+	require.NoError(t, err)
+	require.NotNil(t, result.ApplicationError)
+	require.Equal(t, byte(8), result.ApplicationError.Code)
+	require.Equal(t, "application too busy for handling readout request", result.Fields["application_error"])
+	require.Equal(t, "86868686", result.Fields["id"])
+
+	result, err = AnalyzeHex(ctx, "0F44B409868686861307742A00000005")
+	require.NoError(t, err)
+	require.NotNil(t, result.Alarm)
+	require.Equal(t, true, result.Fields["alarm"])
+	require.Equal(t, "05", result.Fields["alarm_status_hex"])
+	require.Equal(t, []any{0.0, 2.0}, result.Fields["alarm_bits"])
+}
+
+func TestAnalyzeHexStatus(t *testing.T) {
+	ctx := context.Background()
+	frame := "2C44B409381317051A0D8C00497A76240000046D25AA153A0C03000000000C13000000000F6400000000000000"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4", result.Driver)
+	require.Equal(t, "POWER_LOW MANUFACTURER_0x20", result.Fields["status"])
+	require.True(t, result.Telegram.StatusFlags["status_power_low"])
+}
+
+func TestAnalyzeHexHistory(t *testing.T) {
+	ctx := context.Background()
+	// standard_heat with a storage 1 billing date (2024-12-31), 1200 kWh and 50 l.
+	frame := "3C44B409381317051A0D8C00497A76000000046D25AA153A0C03000000000C1300000000426C1F3C4C06001200004C13500000000F6400000000000000"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4", result.Driver)
+	require.InDelta(t, 0.0, result.Fields["total_heating_kwh"], 1e-9)
+	require.Len(t, result.History, 1)
+	require.Equal(t, 1, result.History[0].Storage)
+	require.Equal(t, time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC), result.History[0].Date)
+	require.InDelta(t, 1200.0, result.History[0].Fields["total_heating_kwh"], 1e-9)
+	require.InDelta(t, 0.05, result.History[0].Fields["total_heating_m3"], 1e-9)
+
+	energy := result.FieldSet().Measurements("energy")
+	require.Len(t, energy, 2)
+	require.Equal(t, 1, energy[1].Storage)
+	require.InDelta(t, 1200.0, energy[1].Value, 1e-9)
+
+	// A malformed billing date (month 13) in the historic slot keeps the
+	// current values.
+	bad := strings.Replace(frame, "426C1F3C", "426C1F3D", 1)
+	result, err = AnalyzeHex(ctx, bad)
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4", result.Driver)
+	require.Contains(t, result.Fields, "total_heating_kwh")
+	require.NotContains(t, result.Fields, "history_error")
+	require.ErrorContains(t, result.HistoryErr, "storage 1")
+	require.Empty(t, result.History)
+	require.ErrorContains(t, result.MeasurementsErr, "storage 1")
+	energy = result.FieldSet().Measurements("energy")
+	require.Len(t, energy, 1)
+	require.Equal(t, 0, energy[0].Storage)
+
+	// Only the failing slot is dropped: a valid storage 2 slot is kept.
+	two := "48" + strings.Replace(bad[2:], "0F64", "82016C1F3C8C0106001300000F64", 1)
+	result, err = AnalyzeHex(ctx, two)
+	require.NoError(t, err)
+	require.ErrorContains(t, result.HistoryErr, "storage 1")
+	require.Len(t, result.History, 1)
+	require.Equal(t, 2, result.History[0].Storage)
+	require.InDelta(t, 1300.0, result.History[0].Fields["total_heating_kwh"], 1e-9)
+}
+
+func TestAnalyzeHexMeasurements(t *testing.T) {
+	ctx := context.Background()
+	raw, err := os.ReadFile("../../testdata/hydrocalm4/power_unit_jh.hex")
+	require.NoError(t, err)
+	result, err := AnalyzeHex(ctx, strings.TrimSpace(string(raw)))
+	require.NoError(t, err)
+
+	fs := result.FieldSet()
+	value, unit, err := fs.FloatWithUnit("total_heating_kwh")
+	require.NoError(t, err)
+	require.Equal(t, "kWh", unit)
+	require.InDelta(t, 938.384667, value, 1e-6)
+
+	// The meter sends MJ; the measurement keeps the originating record.
+	m, ok := fs.Measurement("total_heating_kwh")
+	require.True(t, ok)
+	require.Equal(t, "energy", m.Quantity)
+	require.NotNil(t, m.Record)
+	info, known := records.DescribeVIF(m.Record.VIF)
+	require.True(t, known)
+	require.Equal(t, "MJ", info.Unit)
+
+	unit, err = fs.Unit("supply_temperature_c")
+	require.NoError(t, err)
+	require.Equal(t, "C", unit)
+	_, err = fs.Unit("status")
+	require.Error(t, err)
+}
+
+func TestFieldSetQuantity(t *testing.T) {
+	ctx := context.Background()
+	raw, err := os.ReadFile("../../testdata/hydrocalm4/power_unit_jh.hex")
+	require.NoError(t, err)
+	result, err := AnalyzeHex(ctx, strings.TrimSpace(string(raw)))
+	require.NoError(t, err)
+	fs := result.FieldSet()
+
+	gj, err := fs.Quantity("total_heating_kwh", "GJ")
+	require.NoError(t, err)
+	require.InDelta(t, 938.384667*0.0036, gj, 1e-6)
+
+	litres, err := fs.Quantity("total_heating_m3", "l")
+	require.NoError(t, err)
+	require.InDelta(t, 58409, litres, 1e-6)
+
+	_, err = fs.Quantity("total_heating_kwh", "m3")
+	require.ErrorIs(t, err, units.ErrIncompatible)
+	_, err = fs.Quantity("status", "kWh")
+	require.Error(t, err)
+}
+
+func TestAnalyzeHexReceiveTime(t *testing.T) {
+	ctx := context.Background()
+	raw, err := os.ReadFile("../../testdata/hydrocalm4/standard_heat.hex")
+	require.NoError(t, err)
+	frame := strings.TrimSpace(string(raw))
+
+	received := time.Date(2025, time.March, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	result, err := AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{ReceivedAt: received})
+	require.NoError(t, err)
+	require.Equal(t, "2025-03-01T11:30:00Z", result.Fields["timestamp"])
+	require.Equal(t, "2025-03-01T11:30:00Z", result.Timestamp)
+	require.True(t, result.ReceivedAt.Equal(received))
+
+	clock := func() time.Time { return time.Date(2024, time.July, 4, 8, 0, 0, 0, time.UTC) }
+	result, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{Clock: clock})
+	require.NoError(t, err)
+	require.Equal(t, "2024-07-04T08:00:00Z", result.Fields["timestamp"])
+
+	result, err = AnalyzeHexWithOptions(ctx, frame, AnalyzeOptions{ReceivedAt: received, CompatTimestamp: true})
+	require.NoError(t, err)
+	require.Equal(t, "1111-11-11T11:11:11Z", result.Fields["timestamp"])
+	require.Equal(t, "1111-11-11T11:11:11Z", result.Timestamp)
+	require.True(t, result.ReceivedAt.Equal(received))
+}
+
+func TestAnalyzeHexInvalidMeterClock(t *testing.T) {
+	ctx := context.Background()
+	// standard_heat and hydrodigit_water with the IV bit set in the type F
+	// meter clock: the clock is dropped, the readings are kept.
+	result, err := AnalyzeHex(ctx, "2C44B409381317051A0D8C00497A76000000046DA5AA153A0C03000000000C13000000000F6400000000000000")
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4", result.Driver)
+	require.NotContains(t, result.Fields, "device_datetime")
+	require.Contains(t, result.Fields, "total_heating_kwh")
+
+	result, err = AnalyzeHex(ctx, "4E44B4098686868613077AF00040052F2F0C1366380000046DA7287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000002F2F2F2F2F2F")
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.NotContains(t, result.Fields, "meter_datetime")
+	require.InDelta(t, 3.866, result.Fields["total_m3"], 1e-6)
+}
+
+func TestAnalyzeHexMalformedMeterClock(t *testing.T) {
+	ctx := context.Background()
+	// standard_heat with month 13 in the type F meter clock: unlike the IV
+	// bit this is an encoding error and is reported.
+	result, err := AnalyzeHex(ctx, "2C44B409381317051A0D8C00497A76000000046D25AA153D0C03000000000C13000000000F6400000000000000")
+	require.NoError(t, err)
+	require.Equal(t, "hydrocalm4", result.Driver)
+	require.Contains(t, result.Fields["error"], "invalid type F datetime")
+	require.NotContains(t, result.Fields, "total_heating_kwh")
+}
+
+func TestAnalyzeHexDateTypes(t *testing.T) {
+	ctx := context.Background()
+	// Hydrocalm4 with a type I meter clock (10:30:45) and a type G due date.
+	result, err := AnalyzeHex(ctx, "3244B409381317051A0D8C00497A76000000066D2D1E0AAF2300026CAF230C03000000000C13000000000F6400000000000000")
+	require.NoError(t, err)
+	require.Equal(t, "2021-03-15 10:30:45", result.Fields["device_datetime"])
+	require.Equal(t, "2021-03-15", result.Fields["due_date"])
+}
+
+func TestAnalyzeHexZeroMeasurements(t *testing.T) {
+	ctx := context.Background()
+	// hydrodigit_water with a meter reading of 0 m3.
+	frame := "4E44B4098686868613077AF00040052F2F0C1300000000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B30000002F2F2F2F2F2F"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	m, ok := result.FieldSet().Measurement("total_m3")
+	require.True(t, ok)
+	require.Zero(t, m.Value)
+	m, ok = result.FieldSet().Measurement("backflow_m3")
+	require.True(t, ok)
+	require.Zero(t, m.Value)
+}
+
+func TestAnalyzeHexMeasurementsMatchFields(t *testing.T) {
+	ctx := context.Background()
+	// hydrodigit_water with a zero volume record ahead of the 3.866 m3 one,
+	// in place of the trailing filler.
+	frame := "4E44B4098686868613077AF00040052F2F0C13000000000C1366380000046D27287E2A0F150E00000000C10000D10000E60000FD00000C01002F0100410100540100680100890000A00000B3000000"
+	result, err := AnalyzeHex(ctx, frame)
+	require.NoError(t, err)
+	require.Equal(t, "hydrodigit", result.Driver)
+	require.InDelta(t, 3.866, result.Fields["total_m3"], 1e-6)
+	volumes := result.FieldSet().Measurements("volume")
+	require.NotEmpty(t, volumes)
+	require.Equal(t, "total_m3", volumes[0].Name)
+	require.Equal(t, result.Fields["total_m3"], volumes[0].Value)
 }
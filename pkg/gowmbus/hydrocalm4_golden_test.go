@@ -21,7 +21,7 @@ func TestHydrocalm4Golden(t *testing.T) {
 		name := name
 		t.Run(name, func(t *testing.T) {
 			hexStr := testutil.LoadHex(t, "hydrocalm4/"+name+".hex")
-			result, err := AnalyzeHexWithOptions(context.Background(), hexStr, AnalyzeOptions{})
+			result, err := AnalyzeHexWithOptions(context.Background(), hexStr, AnalyzeOptions{CompatTimestamp: true})
 			require.NoError(t, err)
 
 			var expected map[string]any
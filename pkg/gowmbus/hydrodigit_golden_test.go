@@ -34,6 +34,7 @@ func TestHydrodigitGolden(t *testing.T) {
 		}
 		t.Run(testName, func(t *testing.T) {
 			hexStr := testutil.LoadHex(t, "hydrodigit/"+tc.name+".hex")
+			tc.opts.CompatTimestamp = true
 			result, err := AnalyzeHexWithOptions(context.Background(), hexStr, tc.opts)
 			if tc.expectError {
 				require.Error(t, err)
@@ -2,13 +2,85 @@ package gowmbus
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/d21d3q/gowmbus/internal/frame"
 	internalopts "github.com/d21d3q/gowmbus/internal/options"
 )
 
 // AnalyzeOptions configures parsing.
 type AnalyzeOptions struct {
 	KeyHex string
+	// LinkCRC selects how link-layer CRCs are handled before parsing.
+	LinkCRC LinkCRC
+	// Driver forces the named driver instead of detecting one from the
+	// telegram header. Leave empty for automatic selection.
+	Driver string
+	// DriverFiles lists declarative driver definitions (JSON or YAML files,
+	// or directories containing them) considered for this call's detection
+	// only. They are not registered globally; use LoadDriverFiles for
+	// drivers every call should see. A parsed file is reused until its
+	// modification time or size changes.
+	DriverFiles []string
+	// ReceivedAt is when the telegram was received. When zero, Clock is
+	// consulted, and time.Now when Clock is nil as well.
+	ReceivedAt time.Time
+	// Clock supplies the receive time when ReceivedAt is zero, e.g. a
+	// receiver's own clock or a fixed clock in tests.
+	Clock func() time.Time
+	// CompatTimestamp reports the fixed "1111-11-11T11:11:11Z" placeholder
+	// in Fields["timestamp"] and Result.Timestamp instead of the receive
+	// time, as wmbusmeters golden outputs expect. Result.ReceivedAt is still
+	// set.
+	CompatTimestamp bool
+}
+
+// LinkCRC describes whether the input still carries EN 13757-4 block CRCs.
+type LinkCRC int
+
+const (
+	// LinkCRCNone expects the receiver to have removed the CRCs already.
+	LinkCRCNone LinkCRC = iota
+	// LinkCRCAuto strips CRCs when the frame layout reveals format A or B.
+	LinkCRCAuto
+	// LinkCRCFormatA validates and strips format A block CRCs.
+	LinkCRCFormatA
+	// LinkCRCFormatB validates and strips format B block CRCs.
+	LinkCRCFormatB
+)
+
+// CRCError reports a link-layer CRC mismatch, i.e. a corrupt radio reception.
+type CRCError = frame.CRCError
+
+// ParseLinkCRC maps the CLI spelling (none, auto, a, b) to a LinkCRC mode.
+func ParseLinkCRC(s string) (LinkCRC, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return LinkCRCNone, nil
+	case "auto":
+		return LinkCRCAuto, nil
+	case "a":
+		return LinkCRCFormatA, nil
+	case "b":
+		return LinkCRCFormatB, nil
+	default:
+		return LinkCRCNone, fmt.Errorf("unknown link CRC mode %q (want none, auto, a or b)", s)
+	}
+}
+
+func (c LinkCRC) strip(data []byte) ([]byte, error) {
+	switch c {
+	case LinkCRCAuto:
+		return frame.StripCRCAuto(data)
+	case LinkCRCFormatA:
+		return frame.StripCRCFormatA(data)
+	case LinkCRCFormatB:
+		return frame.StripCRCFormatB(data)
+	default:
+		return data, nil
+	}
 }
 
 func (opts AnalyzeOptions) toInternal(ctx context.Context) (context.Context, []byte, error) {
@@ -17,5 +89,22 @@ func (opts AnalyzeOptions) toInternal(ctx context.Context) (context.Context, []b
 		return ctx, nil, err
 	}
 	ctx = internalopts.WithSecurityKey(ctx, key)
+	ctx = internalopts.WithReceiveTime(ctx, opts.receiveTime())
+	if opts.CompatTimestamp {
+		ctx = internalopts.WithCompatTimestamp(ctx)
+	}
 	return ctx, key, nil
 }
+
+// receiveTime resolves the receive time from ReceivedAt, Clock or the
+// system clock, in that order.
+func (opts AnalyzeOptions) receiveTime() time.Time {
+	switch {
+	case !opts.ReceivedAt.IsZero():
+		return opts.ReceivedAt
+	case opts.Clock != nil:
+		return opts.Clock()
+	default:
+		return time.Now()
+	}
+}
@@ -0,0 +1,211 @@
+// Package units converts meter readings between units of the same
+// dimension, e.g. MJ to kWh or m3 to litres.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dimension groups units that can be converted into each other.
+type Dimension string
+
+// Supported dimensions.
+const (
+	Energy      Dimension = "energy"
+	Volume      Dimension = "volume"
+	Power       Dimension = "power"
+	VolumeFlow  Dimension = "volume_flow"
+	Temperature Dimension = "temperature"
+	// TemperatureDifference covers differences such as the flow/return
+	// spread, where K, C and F differ in scale only.
+	TemperatureDifference Dimension = "temperature_difference"
+	Pressure              Dimension = "pressure"
+)
+
+var (
+	// ErrUnknownUnit reports a unit symbol the package does not know.
+	ErrUnknownUnit = errors.New("unknown unit")
+	// ErrIncompatible reports a conversion between different dimensions,
+	// such as energy to volume.
+	ErrIncompatible = errors.New("incompatible units")
+)
+
+// Unit describes a unit by its offset and scale relative to the base unit
+// of its dimension (J, m3, W, m3/s, K and Pa).
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	factor    float64
+	offset    float64
+}
+
+// toBase converts v into the base unit of the dimension.
+func (u Unit) toBase(v float64) float64 { return v*u.factor + u.offset }
+
+// fromBase converts v from the base unit of the dimension.
+func (u Unit) fromBase(v float64) float64 { return (v - u.offset) / u.factor }
+
+const (
+	litre     = 1e-3
+	cubicFoot = 0.028316846592
+	gallon    = 3.785411784e-3 // US liquid gallon
+)
+
+var (
+	catalogue   = map[string]Unit{}
+	differences = map[string]Unit{}
+)
+
+func init() {
+	add := func(dim Dimension, symbol string, factor, offset float64) {
+		catalogue[symbol] = Unit{Symbol: symbol, Dimension: dim, factor: factor, offset: offset}
+	}
+	add(Energy, "J", 1, 0)
+	add(Energy, "kJ", 1e3, 0)
+	add(Energy, "MJ", 1e6, 0)
+	add(Energy, "GJ", 1e9, 0)
+	add(Energy, "Wh", 3600, 0)
+	add(Energy, "kWh", 3.6e6, 0)
+	add(Energy, "MWh", 3.6e9, 0)
+	add(Energy, "GWh", 3.6e12, 0)
+
+	add(Volume, "m3", 1, 0)
+	add(Volume, "l", litre, 0)
+	add(Volume, "ft3", cubicFoot, 0)
+	add(Volume, "gal", gallon, 0)
+
+	add(Power, "W", 1, 0)
+	add(Power, "kW", 1e3, 0)
+	add(Power, "MW", 1e6, 0)
+	add(Power, "J/h", 1.0/3600, 0)
+	add(Power, "kJ/h", 1e3/3600, 0)
+	add(Power, "MJ/h", 1e6/3600, 0)
+	add(Power, "GJ/h", 1e9/3600, 0)
+
+	add(VolumeFlow, "m3/s", 1, 0)
+	add(VolumeFlow, "m3/min", 1.0/60, 0)
+	add(VolumeFlow, "m3/h", 1.0/3600, 0)
+	add(VolumeFlow, "l/s", litre, 0)
+	add(VolumeFlow, "l/min", litre/60, 0)
+	add(VolumeFlow, "l/h", litre/3600, 0)
+	add(VolumeFlow, "ft3/h", cubicFoot/3600, 0)
+	add(VolumeFlow, "gal/min", gallon/60, 0)
+
+	add(Temperature, "K", 1, 0)
+	add(Temperature, "C", 1, 273.15)
+	add(Temperature, "F", 5.0/9, 273.15-32*5.0/9)
+
+	// Temperature differences share their symbols with absolute temperatures
+	// and are only reached through ConvertQuantity.
+	differences["K"] = Unit{Symbol: "K", Dimension: TemperatureDifference, factor: 1}
+	differences["C"] = Unit{Symbol: "C", Dimension: TemperatureDifference, factor: 1}
+	differences["F"] = Unit{Symbol: "F", Dimension: TemperatureDifference, factor: 5.0 / 9}
+
+	add(Pressure, "Pa", 1, 0)
+	add(Pressure, "kPa", 1e3, 0)
+	add(Pressure, "mbar", 100, 0)
+	add(Pressure, "bar", 1e5, 0)
+	add(Pressure, "psi", 6894.757293168, 0)
+}
+
+// aliases maps alternative spellings onto catalogue symbols.
+var aliases = map[string]string{
+	"L":     "l",
+	"litre": "l",
+	"liter": "l",
+	"degC":  "C",
+	"degF":  "F",
+}
+
+// Lookup returns the unit for symbol. Superscript three and the degree sign
+// are accepted, so "m³" and "°C" resolve to m3 and C.
+func Lookup(symbol string) (Unit, error) {
+	s := strings.TrimSpace(symbol)
+	s = strings.ReplaceAll(s, "³", "3")
+	s = strings.TrimPrefix(s, "°")
+	if alias, ok := aliases[s]; ok {
+		s = alias
+	}
+	if u, ok := catalogue[s]; ok {
+		return u, nil
+	}
+	if strings.HasPrefix(s, "L/") {
+		if u, ok := catalogue["l"+s[1:]]; ok {
+			return u, nil
+		}
+	}
+	return Unit{}, fmt.Errorf("%w %q", ErrUnknownUnit, symbol)
+}
+
+// Convert converts value from one unit to another of the same dimension.
+// Temperatures are absolute; use ConvertQuantity for temperature
+// differences.
+func Convert(value float64, from, to string) (float64, error) {
+	src, err := Lookup(from)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := Lookup(to)
+	if err != nil {
+		return 0, err
+	}
+	return convert(value, src, dst, from, to)
+}
+
+// ConvertQuantity converts like Convert, taking the measured quantity into
+// account: for "temperature_difference" (VIF 0x60-0x63) K, C and F are
+// converted without the scale offsets, so a 5 K spread stays 5 C.
+func ConvertQuantity(value float64, quantity, from, to string) (float64, error) {
+	if quantity != string(TemperatureDifference) {
+		return Convert(value, from, to)
+	}
+	src, err := lookupDifference(from)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := lookupDifference(to)
+	if err != nil {
+		return 0, err
+	}
+	return convert(value, src, dst, from, to)
+}
+
+// lookupDifference resolves symbol as a temperature-difference unit; other
+// units are reported with their own dimension so the conversion fails.
+func lookupDifference(symbol string) (Unit, error) {
+	u, err := Lookup(symbol)
+	if err != nil {
+		return Unit{}, err
+	}
+	if diff, ok := differences[u.Symbol]; ok {
+		return diff, nil
+	}
+	return u, nil
+}
+
+func convert(value float64, src, dst Unit, from, to string) (float64, error) {
+	if src.Dimension != dst.Dimension {
+		return 0, fmt.Errorf("%w: %s is %s, %s is %s", ErrIncompatible, from, src.Dimension, to, dst.Dimension)
+	}
+	if src.Symbol == dst.Symbol {
+		return value, nil
+	}
+	return dst.fromBase(src.toBase(value)), nil
+}
+
+// Symbols lists the known unit symbols of a dimension in sorted order.
+func Symbols(dim Dimension) []string {
+	var out []string
+	for _, table := range []map[string]Unit{catalogue, differences} {
+		for symbol, u := range table {
+			if u.Dimension == dim {
+				out = append(out, symbol)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
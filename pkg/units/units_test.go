@@ -0,0 +1,68 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{3.6, "MJ", "kWh", 1},
+		{1000, "kWh", "GJ", 3.6},
+		{1, "MWh", "kWh", 1000},
+		{2.5, "m3", "l", 2500},
+		{1, "m³", "ft3", 35.31466672},
+		{1, "gal", "l", 3.785411784},
+		{3.6, "MJ/h", "kW", 1},
+		{1, "m3/h", "l/min", 1000.0 / 60},
+		{20, "°C", "K", 293.15},
+		{100, "C", "F", 212},
+		{1.5, "bar", "kPa", 150},
+		{7, "kWh", "kWh", 7},
+	}
+	for _, tc := range cases {
+		got, err := Convert(tc.value, tc.from, tc.to)
+		require.NoError(t, err, "%s -> %s", tc.from, tc.to)
+		require.InDelta(t, tc.want, got, 1e-6, "%s -> %s", tc.from, tc.to)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	_, err := Convert(1, "kWh", "m3")
+	require.ErrorIs(t, err, ErrIncompatible)
+
+	_, err = Convert(1, "kWh", "furlong")
+	require.ErrorIs(t, err, ErrUnknownUnit)
+}
+
+func TestLookup(t *testing.T) {
+	u, err := Lookup("L/h")
+	require.NoError(t, err)
+	require.Equal(t, "l/h", u.Symbol)
+	require.Equal(t, VolumeFlow, u.Dimension)
+	require.Contains(t, Symbols(Energy), "GJ")
+}
+
+func TestConvertQuantityTemperatureDifference(t *testing.T) {
+	got, err := ConvertQuantity(5, "temperature_difference", "K", "C")
+	require.NoError(t, err)
+	require.InDelta(t, 5, got, 1e-9)
+
+	got, err = ConvertQuantity(5, "temperature_difference", "K", "F")
+	require.NoError(t, err)
+	require.InDelta(t, 9, got, 1e-9)
+
+	// Absolute temperatures keep the offset.
+	got, err = ConvertQuantity(293.15, "flow_temperature", "K", "C")
+	require.NoError(t, err)
+	require.InDelta(t, 20, got, 1e-9)
+
+	_, err = ConvertQuantity(5, "temperature_difference", "K", "bar")
+	require.ErrorIs(t, err, ErrIncompatible)
+	require.Equal(t, []string{"C", "F", "K"}, Symbols(TemperatureDifference))
+}